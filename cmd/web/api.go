@@ -0,0 +1,389 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"jackson.software/snippetbox/pkg/forms"
+	"jackson.software/snippetbox/pkg/models"
+)
+
+// MaxAPIRequestSize is the maximum size of the body of an API request.
+const maxAPIRequestSize = 1 << 20
+
+// APISnippet is the JSON representation of a snippet. Expires is null for
+// snippets which never expire.
+type apiSnippet struct {
+	ID               int        `json:"id"`
+	Slug             string     `json:"slug,omitempty"`
+	URL              string     `json:"url"`
+	UserID           int        `json:"user_id,omitempty"`
+	Author           string     `json:"author,omitempty"`
+	Title            string     `json:"title"`
+	Content          string     `json:"content"`
+	Language         string     `json:"language"`
+	Filename         string     `json:"filename,omitempty"`
+	Files            []apiFile  `json:"files"`
+	Visibility       string     `json:"visibility"`
+	BurnAfterReading bool       `json:"burn_after_reading"`
+	Protected        bool       `json:"protected"`
+	Tags             []string   `json:"tags"`
+	Created          time.Time  `json:"created"`
+	Expires          *time.Time `json:"expires"`
+}
+
+// APIFile is the JSON representation of one of the files of a snippet after
+// its first one, whose content, language and filename are the snippet's.
+type apiFile struct {
+	Filename string `json:"filename"`
+	Language string `json:"language"`
+	Content  string `json:"content"`
+}
+
+// NewAPISnippet returns the JSON representation of the given snippet.
+func newAPISnippet(s *models.Snippet) *apiSnippet {
+	as := &apiSnippet{
+		ID:               s.ID,
+		Slug:             s.Slug,
+		URL:              s.Path(),
+		UserID:           s.UserID,
+		Author:           s.Author,
+		Title:            s.Title,
+		Content:          s.Content,
+		Language:         s.Language,
+		Filename:         s.Filename,
+		Files:            []apiFile{},
+		Visibility:       string(s.Visibility),
+		BurnAfterReading: s.BurnAfterReading,
+		Protected:        s.Protected,
+		Tags:             s.Tags,
+		Created:          s.Created.UTC(),
+	}
+	if as.Tags == nil {
+		as.Tags = []string{}
+	}
+	for _, f := range s.Files {
+		as.Files = append(as.Files, apiFile{Filename: f.Filename, Language: f.Language, Content: f.Content})
+	}
+	if !s.Expires.IsZero() {
+		expires := s.Expires.UTC()
+		as.Expires = &expires
+	}
+
+	return as
+}
+
+// APISnippetRequest is the body of a request creating or updating a snippet.
+// Its fields are named like the ones of the HTML forms, so it's validated by
+// turning it into such a form.
+type apiSnippetRequest struct {
+	Title            string    `json:"title"`
+	Content          string    `json:"content"`
+	Language         string    `json:"language"`
+	Filename         string    `json:"filename"`
+	Files            []apiFile `json:"files"`
+	Visibility       string    `json:"visibility"`
+	Tags             []string  `json:"tags"`
+	Expires          string    `json:"expires"`
+	ExpiresAt        string    `json:"expires_at"`
+	BurnAfterReading bool      `json:"burn_after_reading"`
+	Password         string    `json:"password"`
+	RemovePassword   bool      `json:"remove_password"`
+}
+
+// Form returns the request as the form the HTML handlers would have received.
+func (req *apiSnippetRequest) form() *forms.Form {
+	values := url.Values{
+		"title":      {req.Title},
+		"content":    {req.Content},
+		"language":   {req.Language},
+		"visibility": {req.Visibility},
+		"tags":       {strings.Join(req.Tags, ",")},
+		"expires":    {req.Expires},
+		"expires_at": {req.ExpiresAt},
+		"password":   {req.Password},
+		"filename":   {req.Filename},
+	}
+	for i, f := range req.Files {
+		values.Set(fileField("filename", i+2), f.Filename)
+		values.Set(fileField("language", i+2), f.Language)
+		values.Set(fileField("content", i+2), f.Content)
+	}
+	if req.BurnAfterReading {
+		values.Set("burn_after_reading", "true")
+	}
+	if req.RemovePassword {
+		values.Set("remove_password", "true")
+	}
+
+	return forms.New(values)
+}
+
+// APIError is the body of every API response telling something went wrong.
+// Errors holds the error messages of every invalid field of a request.
+type apiError struct {
+	Error  string              `json:"error"`
+	Errors map[string][]string `json:"errors,omitempty"`
+}
+
+// APIListSnippets handler sends a page of public snippets, newest first, along
+// with the cursors of the neighbouring pages. Snippets protected by a password
+// or burning after reading are sent without their content and files, which
+// can only be read by showing them one at a time.
+func (app *application) apiListSnippets(w http.ResponseWriter, r *http.Request) {
+	cursor, err := parseCursor(r.URL.Query())
+	if err != nil {
+		app.apiClientError(w, http.StatusBadRequest)
+		return
+	}
+
+	s, err := app.snippets.Page(cursor, snippetsPerPage+1)
+	if err != nil {
+		app.apiServerError(w, err)
+		return
+	}
+
+	s, p := paginate(cursor, s, snippetsPerPage)
+	snippets := make([]*apiSnippet, len(s))
+	for i := range s {
+		snippets[i] = newAPISnippet(s[i])
+		if s[i].Protected || s[i].BurnAfterReading {
+			snippets[i].Content = ""
+			snippets[i].Files = []apiFile{}
+		}
+	}
+
+	app.writeJSON(w, http.StatusOK, struct {
+		Snippets []*apiSnippet `json:"snippets"`
+		Newer    string        `json:"newer,omitempty"`
+		Older    string        `json:"older,omitempty"`
+	}{snippets, p.Newer, p.Older})
+}
+
+// APIShowSnippet handler sends a snippet, following the same rules as
+// showSnippet. Snippets protected by a password can't be read through the API
+// unless they have been unlocked in the session.
+func (app *application) apiShowSnippet(w http.ResponseWriter, r *http.Request) {
+	s, err := app.readSnippet(r)
+	if err != nil {
+		if errors.Is(err, errLocked) {
+			app.apiClientError(w, http.StatusForbidden)
+		} else if errors.Is(err, models.ErrNoRecord) {
+			app.apiClientError(w, http.StatusNotFound)
+		} else if errors.Is(err, models.ErrBurned) {
+			app.apiClientError(w, http.StatusGone)
+		} else {
+			app.apiServerError(w, err)
+		}
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, newAPISnippet(s))
+}
+
+// APICreateSnippet handler creates a snippet from the fields of the create
+// form and sends it along with its location.
+func (app *application) apiCreateSnippet(w http.ResponseWriter, r *http.Request) {
+	var req apiSnippetRequest
+	if !app.decodeJSON(w, r, &req) {
+		return
+	}
+
+	form := req.form()
+	validateNewSnippet(form)
+
+	if !form.Valid() {
+		app.apiValidationError(w, form)
+		return
+	}
+
+	s, err := app.insertSnippet(r, form, 0)
+	if err != nil {
+		app.apiServerError(w, err)
+		return
+	}
+
+	// The snippet is loaded again to get its creation time and author.
+	s, err = app.snippets.Peek(s.ID)
+	if err != nil {
+		app.apiServerError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", "/api/v1"+s.Path())
+	app.writeJSON(w, http.StatusCreated, newAPISnippet(s))
+}
+
+// APIUpdateSnippet handler replaces a snippet with the fields of the edit form
+// and sends the updated snippet.
+func (app *application) apiUpdateSnippet(w http.ResponseWriter, r *http.Request) {
+	s := app.snippetFromContext(r)
+
+	var req apiSnippetRequest
+	if !app.decodeJSON(w, r, &req) {
+		return
+	}
+
+	form := req.form()
+	validateSnippet(form)
+
+	if !form.Valid() {
+		app.apiValidationError(w, form)
+		return
+	}
+
+	err := app.updateSnippet(s, form)
+	if err != nil {
+		app.apiServerError(w, err)
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, newAPISnippet(s))
+}
+
+// APIDeleteSnippet handler deletes a snippet and sends no content.
+func (app *application) apiDeleteSnippet(w http.ResponseWriter, r *http.Request) {
+	s := app.snippetFromContext(r)
+
+	err := app.snippets.Delete(s.ID)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.apiClientError(w, http.StatusNotFound)
+		} else {
+			app.apiServerError(w, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// WriteJSON sends the given value as JSON with the given status code.
+func (app *application) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		app.apiServerError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(b, '\n'))
+}
+
+// DecodeJSON decodes the JSON body of the given request into v. If the body
+// is no valid JSON, has unknown fields or is too large, a 400 bad request
+// response is sent and false is returned.
+func (app *application) decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAPIRequestSize))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(v); err != nil {
+		app.writeJSON(w, http.StatusBadRequest, apiError{Error: fmt.Sprintf("Invalid JSON body: %s", err)})
+		return false
+	}
+
+	return true
+}
+
+// APIServerError writes an error message and stack trace to the error log
+// just like serverError, and then sends a generic 500 server error as JSON.
+func (app *application) apiServerError(w http.ResponseWriter, err error) {
+	trace := fmt.Sprintf("%s\n%s", err.Error(), debug.Stack())
+	app.errorLog.Output(2, trace)
+
+	app.apiClientError(w, http.StatusInternalServerError)
+}
+
+// APIClientError sends the description of the given status code as JSON.
+func (app *application) apiClientError(w http.ResponseWriter, status int) {
+	app.writeJSON(w, status, apiError{Error: http.StatusText(status)})
+}
+
+// APIValidationError sends the errors of the given invalid form as JSON with a
+// 422 unprocessable entity status code.
+func (app *application) apiValidationError(w http.ResponseWriter, form *forms.Form) {
+	app.writeJSON(w, http.StatusUnprocessableEntity, apiError{
+		Error:  http.StatusText(http.StatusUnprocessableEntity),
+		Errors: form.Errors,
+	})
+}
+
+// APIRequireAuthentication checks if the request comes from a user who is
+// logged in, just like requireAuthentication, but sends a 401 unauthorized
+// response instead of redirecting to the login page.
+func (app *application) apiRequireAuthentication(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !app.isAuthenticated(r) {
+			app.apiClientError(w, http.StatusUnauthorized)
+			return
+		}
+
+		w.Header().Add("Cache-Control", "no-store")
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// APIRequireScope only lets requests through which haven't been authenticated
+// by a token, or whose token has the given scope. Requests authenticated by a
+// session may do everything their user may do, just like on the site.
+func (app *application) apiRequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if t := app.tokenFromContext(r); t != nil && !t.HasScope(scope) {
+				app.apiClientError(w, http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// APIRequireSnippetOwner loads the snippet found by the id route parameter
+// just like requireSnippetOwner, but sends its errors as JSON.
+func (app *application) apiRequireSnippetOwner(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s, err := app.snippetFromPath(r)
+		if err != nil {
+			if errors.Is(err, models.ErrNoRecord) || errors.Is(err, models.ErrBurned) {
+				app.apiClientError(w, http.StatusNotFound)
+			} else {
+				app.apiServerError(w, err)
+			}
+			return
+		}
+
+		if !app.isSnippetOwner(r, s) {
+			app.apiClientError(w, http.StatusForbidden)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), contextKeySnippet, s)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// APIRequireJSON only lets requests through which send a JSON body. As the
+// API isn't protected against CSRF, this keeps other sites from submitting
+// forms to it, since browsers don't send JSON across sites without asking.
+func (app *application) apiRequireJSON(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil || mediaType != "application/json" {
+			app.apiClientError(w, http.StatusUnsupportedMediaType)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"jackson.software/snippetbox/pkg/models"
+	"jackson.software/snippetbox/pkg/models/memory"
+)
+
+func TestNewAPISnippet(t *testing.T) {
+	created := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		snippet *models.Snippet
+		want    []string
+	}{
+		{
+			name:    "Never expires",
+			snippet: &models.Snippet{ID: 1, Title: "Runbook", Visibility: models.VisibilityPublic, Created: created},
+			want:    []string{`"url":"/snippets/1"`, `"tags":[]`, `"expires":null`},
+		},
+		{
+			name:    "Unlisted",
+			snippet: &models.Snippet{ID: 2, Slug: "abc", Visibility: models.VisibilityUnlisted, Created: created, Expires: created.Add(time.Hour)},
+			want:    []string{`"slug":"abc"`, `"url":"/snippets/abc"`, `"expires":"2021-03-01T11:00:00Z"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := json.Marshal(newAPISnippet(tt.snippet))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, want := range tt.want {
+				if !strings.Contains(string(b), want) {
+					t.Errorf("want %s in %s", want, b)
+				}
+			}
+		})
+	}
+}
+
+func TestAPIListSnippets(t *testing.T) {
+	app := &application{snippets: &memory.SnippetRepository{DB: memory.New()}}
+
+	files := []*models.File{{Filename: "b.txt", Content: "secret"}}
+	for _, s := range []*models.Snippet{
+		{Title: "Open", Content: "open", Filename: "a.txt", Files: []*models.File{{Filename: "b.txt", Content: "open"}}},
+		{Title: "Protected", Content: "secret", Filename: "a.txt", Files: files, Password: "pa55word123"},
+		{Title: "Burns", Content: "secret", Filename: "a.txt", Files: files, BurnAfterReading: true},
+	} {
+		if _, err := app.snippets.Insert(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	app.apiListSnippets(rr, httptest.NewRequest(http.MethodGet, "/api/v1/snippets", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want status %d; got %d", http.StatusOK, rr.Code)
+	}
+	if strings.Contains(rr.Body.String(), "secret") {
+		t.Errorf("want no content of protected and burning snippets; got %s", rr.Body)
+	}
+
+	var body struct{ Snippets []apiSnippet }
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Snippets) != 3 || body.Snippets[2].Content != "open" || len(body.Snippets[2].Files) != 1 {
+		t.Errorf("want all 3 snippets and the content of the open one; got %+v", body.Snippets)
+	}
+
+	if _, err := app.snippets.Get(3); err != nil {
+		t.Errorf("want the snippet burning after reading not to be burned by the list; got %v", err)
+	}
+}
+
+func TestAPIRequireScope(t *testing.T) {
+	app := newTestApplication(t)
+	insertTestUsers(t, app, "alice")
+	insertTestSnippet(t, app, &models.Snippet{UserID: 1})
+	insertTestSnippet(t, app, &models.Snippet{UserID: 1})
+
+	tokens := map[string]string{}
+	for name, scopes := range map[string][]string{
+		"read":  {models.ScopeSnippetsRead},
+		"write": {models.ScopeSnippetsWrite},
+	} {
+		token, err := app.tokens.Insert(&models.Token{UserID: 1, Name: name, Scopes: scopes})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tokens[name] = token
+	}
+
+	ts := newTestServer(t, app.routes())
+	client := ts.newClient(t)
+
+	body := `{"title": "Build log", "content": "ok", "expires": "168h"}`
+	tests := []struct {
+		name     string
+		token    string
+		method   string
+		urlPath  string
+		wantCode int
+	}{
+		{"List with read scope", tokens["read"], http.MethodGet, "/api/v1/snippets", http.StatusOK},
+		{"Show with read scope", tokens["read"], http.MethodGet, "/api/v1/snippets/1", http.StatusOK},
+		{"Create with read scope", tokens["read"], http.MethodPost, "/api/v1/snippets", http.StatusForbidden},
+		{"Update with read scope", tokens["read"], http.MethodPut, "/api/v1/snippets/1", http.StatusForbidden},
+		{"Delete with read scope", tokens["read"], http.MethodDelete, "/api/v1/snippets/1", http.StatusForbidden},
+		{"List with write scope", tokens["write"], http.MethodGet, "/api/v1/snippets", http.StatusForbidden},
+		{"Create with write scope", tokens["write"], http.MethodPost, "/api/v1/snippets", http.StatusCreated},
+		{"Update with write scope", tokens["write"], http.MethodPut, "/api/v1/snippets/1", http.StatusOK},
+		{"Delete with write scope", tokens["write"], http.MethodDelete, "/api/v1/snippets/2", http.StatusNoContent},
+		{"Create with invalid token", "sbx_invalid", http.MethodPost, "/api/v1/snippets", http.StatusUnauthorized},
+		{"List without token", "", http.MethodGet, "/api/v1/snippets", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, ts.URL+tt.urlPath, strings.NewReader(body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+
+			code, _, _ := client.do(t, req)
+			if code != tt.wantCode {
+				t.Errorf("want status %d; got %d", tt.wantCode, code)
+			}
+		})
+	}
+}
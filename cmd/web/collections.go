@@ -0,0 +1,366 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-zoo/bone"
+	"jackson.software/snippetbox/pkg/forms"
+	"jackson.software/snippetbox/pkg/models"
+)
+
+// CollectionMember is a snippet as it's shown on the page of a collection,
+// along with whether its content may be shown to the user and whether it can
+// be moved up or down.
+type collectionMember struct {
+	*models.Snippet
+	Readable    bool
+	First, Last bool
+}
+
+// ShowCollections handler shows the collections of the user, newest first.
+func (app *application) showCollections(w http.ResponseWriter, r *http.Request) {
+	c, err := app.collections.ForUser(app.authenticatedUserID(r))
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	app.render(w, r, "collections.page.tmpl", &templateData{Collections: c})
+}
+
+// ShowCollectionForm handler shows the form creating a collection.
+func (app *application) showCollectionForm(w http.ResponseWriter, r *http.Request) {
+	app.render(w, r, "create_collection.page.tmpl", &templateData{Form: forms.New(nil)})
+}
+
+// CreateCollection handler creates an empty collection owned by the user.
+// Collections which aren't public get a slug, just like snippets.
+func (app *application) createCollection(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	form := forms.New(r.PostForm)
+	form.Required("title")
+	form.MaxLength("title", 100)
+	form.MaxLength("description", 1000)
+	form.PermittedValues("visibility", visibilities...)
+
+	if !form.Valid() {
+		app.render(w, r, "create_collection.page.tmpl", &templateData{Form: form})
+		return
+	}
+
+	c := &models.Collection{
+		UserID:      app.authenticatedUserID(r),
+		Title:       form.Get("title"),
+		Description: form.Get("description"),
+		Visibility:  snippetVisibility(form),
+	}
+	if c.Visibility != models.VisibilityPublic {
+		c.Slug, err = models.NewSlug()
+		if err != nil {
+			app.serverError(w, err)
+			return
+		}
+	}
+
+	c.ID, err = app.collections.Insert(c)
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	app.session.Put(r, "flash", "Your collection was successfully created")
+
+	http.Redirect(w, r, c.Path(), http.StatusSeeOther)
+}
+
+// ShowCollection handler shows a collection along with its snippets.
+func (app *application) showCollection(w http.ResponseWriter, r *http.Request) {
+	c, err := app.collectionFromPath(r)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w)
+		} else {
+			app.serverError(w, err)
+		}
+		return
+	}
+
+	app.renderCollection(w, r, c, forms.New(nil))
+}
+
+// RenderCollection renders the page of the given collection along with the
+// given form adding a snippet to it.
+func (app *application) renderCollection(w http.ResponseWriter, r *http.Request, c *models.Collection, form *forms.Form) {
+	s, err := app.collectionSnippets(r, c)
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	members := make([]collectionMember, len(s))
+	for i := range s {
+		members[i] = collectionMember{
+			Snippet:  s[i],
+			Readable: app.isUnlocked(r, s[i]) && !s[i].BurnAfterReading,
+			First:    i == 0,
+			Last:     i == len(s)-1,
+		}
+	}
+
+	app.render(w, r, "collection.page.tmpl", &templateData{Collection: c, Form: form, Members: members})
+}
+
+// DeleteCollection handler deletes a collection of the user, but not its
+// snippets.
+func (app *application) deleteCollection(w http.ResponseWriter, r *http.Request) {
+	c, ok := app.ownCollection(w, r)
+	if !ok {
+		return
+	}
+
+	err := app.collections.Delete(c.ID)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w)
+		} else {
+			app.serverError(w, err)
+		}
+		return
+	}
+
+	app.session.Put(r, "flash", "Your collection was successfully deleted")
+
+	http.Redirect(w, r, "/me/collections", http.StatusSeeOther)
+}
+
+// AddCollectionSnippet handler adds the snippet given by the snippet field to
+// the end of a collection of the user. The snippet is given by its ID, its
+// slug or its URL, and has to be one the user may read. Snippets which burn
+// after reading, and unlisted or private snippets of other users, can't be
+// added.
+func (app *application) addCollectionSnippet(w http.ResponseWriter, r *http.Request) {
+	c, ok := app.ownCollection(w, r)
+	if !ok {
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	form := forms.New(r.PostForm)
+	form.Required("snippet")
+
+	if form.Valid() {
+		s, err := app.snippetFromRef(r, snippetRefFromInput(form.Get("snippet")))
+		if err != nil && !errors.Is(err, models.ErrNoRecord) && !errors.Is(err, models.ErrBurned) {
+			app.serverError(w, err)
+			return
+		}
+
+		if err != nil {
+			form.Errors.Add("snippet", "This snippet doesn't exist")
+		} else if !app.isUnlocked(r, s) || s.BurnAfterReading || s.Visibility != models.VisibilityPublic && !app.isSnippetOwner(r, s) {
+			form.Errors.Add("snippet", "This snippet can't be added to a collection")
+		} else if err = app.collections.AddSnippet(c.ID, s.ID); errors.Is(err, models.ErrDuplicateSnippet) {
+			form.Errors.Add("snippet", "This snippet is in the collection already")
+		} else if err != nil {
+			app.serverError(w, err)
+			return
+		}
+	}
+
+	if !form.Valid() {
+		app.renderCollection(w, r, c, form)
+		return
+	}
+
+	app.session.Put(r, "flash", "The snippet was successfully added")
+
+	http.Redirect(w, r, c.Path(), http.StatusSeeOther)
+}
+
+// RemoveCollectionSnippet handler removes the snippet with the ID given by the
+// snippet_id field from a collection of the user.
+func (app *application) removeCollectionSnippet(w http.ResponseWriter, r *http.Request) {
+	c, ok := app.ownCollection(w, r)
+	if !ok {
+		return
+	}
+
+	snippetID, err := strconv.Atoi(r.PostFormValue("snippet_id"))
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	err = app.collections.RemoveSnippet(c.ID, snippetID)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w)
+		} else {
+			app.serverError(w, err)
+		}
+		return
+	}
+
+	app.session.Put(r, "flash", "The snippet was successfully removed")
+
+	http.Redirect(w, r, c.Path(), http.StatusSeeOther)
+}
+
+// MoveCollectionSnippet handler moves the snippet with the ID given by the
+// snippet_id field one place up or down in a collection of the user, as given
+// by the direction field. The snippet is moved past the neighbour the user
+// sees, skipping those hidden from the page.
+func (app *application) moveCollectionSnippet(w http.ResponseWriter, r *http.Request) {
+	c, ok := app.ownCollection(w, r)
+	if !ok {
+		return
+	}
+
+	snippetID, err := strconv.Atoi(r.PostFormValue("snippet_id"))
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+	offset := map[string]int{"up": -1, "down": 1}[r.PostFormValue("direction")]
+	if offset == 0 {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	s, err := app.collectionSnippets(r, c)
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	ids := make([]int, len(s))
+	i := -1
+	for j := range s {
+		ids[j] = s[j].ID
+		if s[j].ID == snippetID {
+			i = j
+		}
+	}
+	if i == -1 {
+		app.notFound(w)
+		return
+	}
+
+	if j := i + offset; j >= 0 && j < len(ids) {
+		ids[i], ids[j] = ids[j], ids[i]
+		if err = app.collections.Reorder(c.ID, ids); err != nil {
+			app.serverError(w, err)
+			return
+		}
+	}
+
+	http.Redirect(w, r, c.Path(), http.StatusSeeOther)
+}
+
+// CollectionSnippets returns the snippets of the given collection in their
+// order, except for the unlisted and private snippets which the user who made
+// the request doesn't own. A collection may be reached by its ID, so it must
+// not give away the slugs of unlisted snippets.
+func (app *application) collectionSnippets(r *http.Request, c *models.Collection) ([]*models.Snippet, error) {
+	s, err := app.snippets.ForCollection(c.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	visible := []*models.Snippet{}
+	for _, snippet := range s {
+		if snippet.Visibility == models.VisibilityPublic || app.isSnippetOwner(r, snippet) {
+			visible = append(visible, snippet)
+		}
+	}
+
+	return visible, nil
+}
+
+// CollectionFromPath loads the collection referenced by the id route
+// parameter, just like collectionFromRef does.
+func (app *application) collectionFromPath(r *http.Request) (*models.Collection, error) {
+	return app.collectionFromRef(r, bone.GetValue(r, "id"))
+}
+
+// CollectionFromRef loads the collection with the given reference, following
+// the same rules as snippetFromRef: collections which aren't public can only
+// be found by their slug, and private collections only by their owner.
+func (app *application) collectionFromRef(r *http.Request, ref string) (*models.Collection, error) {
+	var c *models.Collection
+	id, err := strconv.Atoi(ref)
+	if err != nil {
+		c, err = app.collections.GetBySlug(ref)
+	} else if id < 1 {
+		err = models.ErrNoRecord
+	} else if c, err = app.collections.Get(id); err == nil && c.Visibility != models.VisibilityPublic {
+		err = models.ErrNoRecord
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Visibility == models.VisibilityPrivate && !app.isCollectionOwner(r, c) {
+		return nil, models.ErrNoRecord
+	}
+
+	return c, nil
+}
+
+// IsCollectionOwner returns true if the request was made by the user who
+// created the given collection.
+func (app *application) isCollectionOwner(r *http.Request, c *models.Collection) bool {
+	userID := app.authenticatedUserID(r)
+
+	return userID != 0 && c.UserID == userID
+}
+
+// OwnCollection loads the collection referenced by the id route parameter for
+// the handlers changing it. If it can't be found, or the user who made the
+// request doesn't own it, an error is sent and false is returned.
+func (app *application) ownCollection(w http.ResponseWriter, r *http.Request) (*models.Collection, bool) {
+	c, err := app.collectionFromPath(r)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w)
+		} else {
+			app.serverError(w, err)
+		}
+		return nil, false
+	}
+
+	if !app.isCollectionOwner(r, c) {
+		app.clientError(w, http.StatusForbidden)
+		return nil, false
+	}
+
+	return c, true
+}
+
+// SnippetRefFromInput returns the reference of the snippet given by the user,
+// who may have given its ID, its slug or any of its URLs, eg.
+// "https://example.com/snippets/abc/raw".
+func snippetRefFromInput(input string) string {
+	ref := strings.TrimSpace(input)
+	if i := strings.LastIndex(ref, "/snippets/"); i != -1 {
+		ref = ref[i+len("/snippets/"):]
+	}
+	if i := strings.IndexAny(ref, "/?#"); i != -1 {
+		ref = ref[:i]
+	}
+
+	return ref
+}
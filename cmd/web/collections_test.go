@@ -0,0 +1,110 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"jackson.software/snippetbox/pkg/models"
+)
+
+func TestSnippetRefFromInput(t *testing.T) {
+	tests := map[string]string{
+		"42":                                 "42",
+		" 42 ":                               "42",
+		"Xb3kL9aQ":                           "Xb3kL9aQ",
+		"/snippets/42":                       "42",
+		"https://localhost:4000/snippets/42": "42",
+		"https://localhost:4000/snippets/Xb3kL9aQ/raw": "Xb3kL9aQ",
+		"https://localhost:4000/snippets/42?from=1#L3": "42",
+	}
+
+	for input, want := range tests {
+		if got := snippetRefFromInput(input); got != want {
+			t.Errorf("want %q for %q; got %q", want, input, got)
+		}
+	}
+}
+
+func TestShowCollectionHidesSnippetsOfOthers(t *testing.T) {
+	app := newTestApplication(t)
+	insertTestUsers(t, app, "alice", "bob")
+
+	ids := []int{
+		insertTestSnippet(t, app, &models.Snippet{UserID: 1, Content: "public content", Visibility: models.VisibilityPublic}),
+		insertTestSnippet(t, app, &models.Snippet{UserID: 2, Content: "unlisted content", Visibility: models.VisibilityUnlisted, Slug: "unlistedSlug"}),
+		insertTestSnippet(t, app, &models.Snippet{UserID: 2, Content: "private content", Visibility: models.VisibilityPrivate, Slug: "privateSlug"}),
+	}
+	collectionID, err := app.collections.Insert(&models.Collection{UserID: 1, Title: "Mixed", Visibility: models.VisibilityPublic})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range ids {
+		if err := app.collections.AddSnippet(collectionID, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ts := newTestServer(t, app.routes())
+	anonymous := ts.newClient(t)
+	alice := ts.newClient(t)
+	alice.login(t, "alice@example.com")
+	bob := ts.newClient(t)
+	bob.login(t, "bob@example.com")
+
+	tests := []struct {
+		name     string
+		client   *testClient
+		shown    []string
+		notShown []string
+	}{
+		{"Anonymous", anonymous, []string{"public content"}, []string{"unlisted", "private"}},
+		{"Collection owner", alice, []string{"public content"}, []string{"unlisted", "private"}},
+		{"Snippet owner", bob, []string{"public content", "unlisted content", "private content"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := tt.client.get(t, "/collections/1")
+			if code != http.StatusOK {
+				t.Fatalf("want status %d; got %d", http.StatusOK, code)
+			}
+			for _, s := range tt.shown {
+				if !strings.Contains(body, s) {
+					t.Errorf("want %q in the collection", s)
+				}
+			}
+			for _, s := range tt.notShown {
+				if strings.Contains(body, s) {
+					t.Errorf("want no %q in the collection", s)
+				}
+			}
+		})
+	}
+}
+
+func TestAddCollectionSnippetOfOthers(t *testing.T) {
+	app := newTestApplication(t)
+	insertTestUsers(t, app, "alice", "bob")
+	insertTestSnippet(t, app, &models.Snippet{UserID: 2, Visibility: models.VisibilityUnlisted, Slug: "unlistedSlug"})
+	insertTestSnippet(t, app, &models.Snippet{UserID: 1, Visibility: models.VisibilityUnlisted, Slug: "ownSlug"})
+	if _, err := app.collections.Insert(&models.Collection{UserID: 1, Title: "Mine", Visibility: models.VisibilityPublic}); err != nil {
+		t.Fatal(err)
+	}
+
+	ts := newTestServer(t, app.routes())
+	alice := ts.newClient(t)
+	alice.login(t, "alice@example.com")
+	alice.get(t, "/collections/1")
+
+	code, _, body := alice.postForm(t, "/collections/1/snippets", url.Values{"snippet": {"unlistedSlug"}})
+	if code != http.StatusOK || !strings.Contains(body, "This snippet can&#39;t be added to a collection") {
+		t.Errorf("want the unlisted snippet of another user to be refused; got status %d", code)
+	}
+
+	code, _, _ = alice.postForm(t, "/collections/1/snippets", url.Values{"snippet": {"ownSlug"}})
+	if code != http.StatusSeeOther {
+		t.Errorf("want status %d for adding an own unlisted snippet; got %d", http.StatusSeeOther, code)
+	}
+}
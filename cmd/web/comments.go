@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"jackson.software/snippetbox/pkg/forms"
+	"jackson.software/snippetbox/pkg/highlight"
+	"jackson.software/snippetbox/pkg/models"
+)
+
+// MaxCommentLength is the maximum number of characters of a comment.
+const maxCommentLength = 2000
+
+// CodeLine is a line of a highlighted snippet along with the comments which
+// are attached to it.
+type codeLine struct {
+	Number   int
+	HTML     template.HTML
+	Comments []*models.Comment
+}
+
+// SnippetLines returns the highlighted lines of the given snippet with the
+// given comments attached to them. Comments on the whole snippet, and those on
+// lines which don't exist anymore since the snippet was edited, are returned
+// separately.
+func snippetLines(s *models.Snippet, comments []*models.Comment) ([]codeLine, []*models.Comment, error) {
+	html, err := highlight.Lines(s.Content, s.Language)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lines := make([]codeLine, len(html))
+	for i, h := range html {
+		lines[i] = codeLine{Number: i + 1, HTML: template.HTML(h)}
+	}
+
+	others := []*models.Comment{}
+	for _, c := range comments {
+		if c.Line < 1 || c.Line > len(lines) {
+			others = append(others, c)
+			continue
+		}
+		lines[c.Line-1].Comments = append(lines[c.Line-1].Comments, c)
+	}
+
+	return lines, others, nil
+}
+
+// CountLines returns the number of lines of the given content, as they are
+// shown by snippetLines.
+func countLines(content string) int {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	if content == "" {
+		return 0
+	}
+
+	return strings.Count(strings.TrimSuffix(content, "\n"), "\n") + 1
+}
+
+// CreateComment handler adds a comment to a snippet, attached to one of its
+// lines if the line field is given. Snippets which haven't been unlocked and
+// snippets which burn after reading can't be commented on.
+func (app *application) createComment(w http.ResponseWriter, r *http.Request) {
+	s, err := app.snippetFromPath(r)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) || errors.Is(err, models.ErrBurned) {
+			app.notFound(w)
+		} else {
+			app.serverError(w, err)
+		}
+		return
+	}
+	if !app.isUnlocked(r, s) || s.BurnAfterReading {
+		app.clientError(w, http.StatusForbidden)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	form := forms.New(r.PostForm)
+	form.Required("content")
+	form.MaxLength("content", maxCommentLength)
+	form.Integer("line", 1, countLines(s.Content))
+
+	if !form.Valid() {
+		app.renderSnippet(w, r, s, form)
+		return
+	}
+
+	line, _ := strconv.Atoi(strings.TrimSpace(form.Get("line")))
+	id, err := app.comments.Insert(&models.Comment{
+		SnippetID: s.ID,
+		UserID:    app.authenticatedUserID(r),
+		Line:      line,
+		Content:   form.Get("content"),
+	})
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	app.session.Put(r, "flash", "Your comment was successfully added")
+
+	http.Redirect(w, r, fmt.Sprintf("%s#comment-%d", s.Path(), id), http.StatusSeeOther)
+}
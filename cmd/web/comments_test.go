@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+
+	"jackson.software/snippetbox/pkg/models"
+)
+
+func TestSnippetLines(t *testing.T) {
+	s := &models.Snippet{Content: "package main\n\nfunc main() {}\n", Language: "go"}
+	comments := []*models.Comment{
+		{ID: 1, Line: 0},
+		{ID: 2, Line: 3},
+		{ID: 3, Line: 1},
+		{ID: 4, Line: 4},
+		{ID: 5, Line: 3},
+	}
+
+	lines, others, err := snippetLines(s, comments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(lines) != 3 {
+		t.Fatalf("want 3 lines; got %d", len(lines))
+	}
+	want := [][]int{{3}, {}, {2, 5}}
+	for i, line := range lines {
+		if line.Number != i+1 {
+			t.Errorf("want line number %d; got %d", i+1, line.Number)
+		}
+		if got := commentIDs(line.Comments); !equalInts(got, want[i]) {
+			t.Errorf("want comments %v on line %d; got %v", want[i], line.Number, got)
+		}
+	}
+	if got := commentIDs(others); !equalInts(got, []int{1, 4}) {
+		t.Errorf("want other comments [1 4]; got %v", got)
+	}
+}
+
+func TestCountLines(t *testing.T) {
+	for _, content := range []string{"", "a", "a\n", "a\nb", "a\n\n", "a\r\nb\r\n", "\n\n\n"} {
+		lines, _, err := snippetLines(&models.Snippet{Content: content}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := countLines(content); got != len(lines) {
+			t.Errorf("want %d lines in %q; got %d", len(lines), content, got)
+		}
+	}
+}
+
+func commentIDs(comments []*models.Comment) []int {
+	ids := []int{}
+	for _, c := range comments {
+		ids = append(ids, c.ID)
+	}
+	return ids
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
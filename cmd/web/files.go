@@ -0,0 +1,242 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-zoo/bone"
+	"jackson.software/snippetbox/pkg/diff"
+	"jackson.software/snippetbox/pkg/forms"
+	"jackson.software/snippetbox/pkg/highlight"
+	"jackson.software/snippetbox/pkg/models"
+)
+
+// MaxFiles is the maximum number of files of a snippet, including its first.
+const maxFiles = 10
+
+// MaxFilenameLength is the maximum number of characters of a filename.
+const maxFilenameLength = 100
+
+// FileFieldRX matches the names of the fields of a snippet form which belong
+// to the files after the first one, which are numbered from 2, eg. "content.2".
+// The fields of the first file are named filename, language and content.
+var fileFieldRX = regexp.MustCompile(`^(filename|language|content)\.([2-9]|[1-9][0-9]+)$`)
+
+// FileField returns the name of the given field of the file with the given
+// number in a snippet form.
+func fileField(field string, n int) string {
+	if n == 1 {
+		return field
+	}
+	return fmt.Sprintf("%s.%d", field, n)
+}
+
+// FileNumbers returns the numbers of the files after the first one which are
+// given in the snippet form, in order. Files without a filename and content,
+// like the ones added to the form but left blank, are left out.
+func fileNumbers(form *forms.Form) []int {
+	seen := map[int]bool{}
+	numbers := []int{}
+	for field := range form.Values {
+		m := fileFieldRX.FindStringSubmatch(field)
+		if m == nil {
+			continue
+		}
+
+		n, err := strconv.Atoi(m[2])
+		if err != nil || seen[n] {
+			continue
+		}
+		if strings.TrimSpace(form.Get(fileField("filename", n))) == "" && strings.TrimSpace(form.Get(fileField("content", n))) == "" {
+			continue
+		}
+		seen[n] = true
+		numbers = append(numbers, n)
+	}
+	sort.Ints(numbers)
+
+	return numbers
+}
+
+// ValidateFiles checks the filenames and languages of the files of a snippet
+// form, and the content of the files after the first one. Filenames may only
+// be left out of snippets made of a single file, and have to be unique within
+// a snippet.
+func validateFiles(form *forms.Form) {
+	numbers := fileNumbers(form)
+	if len(numbers) >= maxFiles {
+		form.Errors.Add("files", fmt.Sprintf("A snippet can't have more than %d files", maxFiles))
+		return
+	}
+	if len(numbers) > 0 {
+		form.Required("filename")
+	}
+
+	seen := map[string]bool{}
+	for _, n := range append([]int{1}, numbers...) {
+		filename := fileField("filename", n)
+		if n > 1 {
+			form.Required(filename, fileField("content", n))
+			form.PermittedValues(fileField("language", n), highlight.IDs()...)
+		}
+		form.MaxLength(filename, maxFilenameLength)
+		form.MatchesPattern(filename, forms.FilenameRX)
+
+		name := strings.TrimSpace(form.Get(filename))
+		if name != "" && seen[name] {
+			form.Errors.Add(filename, "Another file already has this name")
+		}
+		seen[name] = true
+	}
+}
+
+// SnippetFiles returns the files after the first one of the given valid
+// snippet form. The language of a file is detected from its filename and
+// content unless one has been chosen.
+func snippetFiles(form *forms.Form) []*models.File {
+	files := []*models.File{}
+	for _, n := range fileNumbers(form) {
+		f := &models.File{
+			Filename: strings.TrimSpace(form.Get(fileField("filename", n))),
+			Language: form.Get(fileField("language", n)),
+			Content:  form.Get(fileField("content", n)),
+		}
+		if f.Language == "" {
+			f.Language = highlight.DetectFile(f.Filename, f.Content)
+		}
+		files = append(files, f)
+	}
+
+	return files
+}
+
+// SetFileValues sets the filename of the first file of the given snippet and
+// the fields of its other files in the given values of a snippet form.
+func setFileValues(values url.Values, s *models.Snippet) {
+	values.Set("filename", s.Filename)
+	for i, f := range s.Files {
+		values.Set(fileField("filename", i+2), f.Filename)
+		values.Set(fileField("language", i+2), f.Language)
+		values.Set(fileField("content", i+2), f.Content)
+	}
+}
+
+// WriteRawFiles writes the content of the given files one after the other,
+// each headed by its filename like "==> run.sh <==" and separated by a blank
+// line, the way head and tail print several files.
+func writeRawFiles(w io.Writer, files []*models.File) {
+	for i, f := range files {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "==> %s <==\n%s", f.Filename, f.Content)
+		if !strings.HasSuffix(f.Content, "\n") {
+			fmt.Fprintln(w)
+		}
+	}
+}
+
+// ZipFiles returns a zip archive of the given files, which are stored under
+// their filenames and dated with the given time.
+func zipFiles(files []*models.File, modified time.Time) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	for _, f := range files {
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: f.Filename, Method: zip.Deflate, Modified: modified})
+		if err != nil {
+			return nil, err
+		}
+		if _, err = io.WriteString(fw, f.Content); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// FileDiff is the unified diff of a file between two revisions of a snippet.
+// Added and Removed tell whether the file only exists in the newer or the
+// older revision.
+type fileDiff struct {
+	Filename string
+	Added    bool
+	Removed  bool
+	Hunks    []diff.Hunk
+}
+
+// DiffFiles compares the files of two revisions of a snippet, matching them by
+// their filenames, and returns the diffs of the files which changed. They're
+// ordered like the files of the newer revision, followed by the files which
+// have been removed since the older one.
+func diffFiles(from, to []*models.File) []fileDiff {
+	old := map[string]*models.File{}
+	for _, f := range from {
+		old[f.Filename] = f
+	}
+
+	diffs := []fileDiff{}
+	for _, f := range to {
+		o, ok := old[f.Filename]
+		if !ok {
+			diffs = append(diffs, fileDiff{Filename: f.Filename, Added: true, Hunks: diff.Unified("", f.Content, 3)})
+			continue
+		}
+		delete(old, f.Filename)
+
+		if hunks := diff.Unified(o.Content, f.Content, 3); len(hunks) > 0 {
+			diffs = append(diffs, fileDiff{Filename: f.Filename, Hunks: hunks})
+		}
+	}
+
+	for _, f := range from {
+		if _, ok := old[f.Filename]; ok {
+			diffs = append(diffs, fileDiff{Filename: f.Filename, Removed: true, Hunks: diff.Unified(f.Content, "", 3)})
+		}
+	}
+
+	return diffs
+}
+
+// ShowRawSnippetFile handler sends the content of the file of a snippet with
+// the filename given by the filename route parameter as plain text, following
+// the same rules as showRawSnippet. The files of a snippet which burns after
+// reading are only served to its owner, since fetching one of them would burn
+// all the others.
+func (app *application) showRawSnippetFile(w http.ResponseWriter, r *http.Request) {
+	filename := bone.GetValue(r, "filename")
+
+	// Look for the file before the snippet is read, as a mistyped filename
+	// would burn a snippet which burns after reading otherwise. The errors of
+	// the lookup are sent by rawSnippet.
+	peeked, err := app.snippetFromPath(r)
+	if err == nil && (peeked.BurnAfterReading && !app.isSnippetOwner(r, peeked) ||
+		app.isUnlocked(r, peeked) && peeked.File(filename) == nil) {
+		app.notFound(w)
+		return
+	}
+
+	s, ok := app.rawSnippet(w, r)
+	if !ok {
+		return
+	}
+
+	f := s.File(filename)
+	if f == nil {
+		app.notFound(w)
+		return
+	}
+
+	w.Write([]byte(f.Content))
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"jackson.software/snippetbox/pkg/forms"
+	"jackson.software/snippetbox/pkg/models"
+)
+
+func TestFileNumbers(t *testing.T) {
+	form := forms.New(url.Values{
+		"filename":    {"Dockerfile"},
+		"content":     {"FROM alpine"},
+		"filename.10": {"run.sh"},
+		"content.10":  {"docker compose up"},
+		"filename.3":  {"docker-compose.yml"},
+		"language.3":  {"yaml"},
+		"content.4":   {" "},
+		"language.5":  {"go"},
+		"content.1":   {"a"},
+		"content.x":   {"a"},
+	})
+
+	if got := fileNumbers(form); !reflect.DeepEqual(got, []int{3, 10}) {
+		t.Errorf("want file numbers [3 10]; got %v", got)
+	}
+}
+
+func TestValidateFiles(t *testing.T) {
+	tests := []struct {
+		name   string
+		values url.Values
+		errors []string
+	}{
+		{"Single unnamed file", url.Values{"content": {"a"}}, nil},
+		{"Several files", url.Values{"filename": {"a.sh"}, "filename.2": {"b.sh"}, "content.2": {"b"}}, nil},
+		{"Unnamed first file", url.Values{"filename.2": {"b.sh"}, "content.2": {"b"}}, []string{"filename"}},
+		{"Unnamed file", url.Values{"filename": {"a.sh"}, "content.2": {"b"}}, []string{"filename.2"}},
+		{"Empty file", url.Values{"filename": {"a.sh"}, "filename.2": {"b.sh"}}, []string{"content.2"}},
+		{"Duplicate filename", url.Values{"filename": {"a.sh"}, "filename.2": {" a.sh"}, "content.2": {"b"}}, []string{"filename.2"}},
+		{"Invalid filename", url.Values{"filename": {"../a.sh"}}, []string{"filename"}},
+		{"Invalid language", url.Values{"filename": {"a"}, "filename.2": {"b"}, "content.2": {"b"}, "language.2": {"cobol"}}, []string{"language.2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := forms.New(tt.values)
+			validateFiles(form)
+
+			got := []string{}
+			for field := range form.Errors {
+				got = append(got, field)
+			}
+			if len(got) != len(tt.errors) || len(got) == 1 && got[0] != tt.errors[0] {
+				t.Errorf("want errors on %v; got %v", tt.errors, form.Errors)
+			}
+		})
+	}
+
+	values := url.Values{"filename": {"0"}}
+	for n := 2; n <= maxFiles+1; n++ {
+		values.Set(fileField("filename", n), fileField("f", n))
+		values.Set(fileField("content", n), "a")
+	}
+	form := forms.New(values)
+	validateFiles(form)
+	if form.Errors.Get("files") == "" {
+		t.Errorf("want an error for %d files; got %v", maxFiles+1, form.Errors)
+	}
+}
+
+func TestSnippetFiles(t *testing.T) {
+	form := forms.New(url.Values{
+		"filename.2": {" docker-compose.yml "},
+		"content.2":  {"services:"},
+		"filename.3": {"notes"},
+		"content.3":  {"a"},
+		"language.3": {"markdown"},
+	})
+
+	want := []*models.File{
+		{Filename: "docker-compose.yml", Language: "yaml", Content: "services:"},
+		{Filename: "notes", Language: "markdown", Content: "a"},
+	}
+	if got := snippetFiles(form); !reflect.DeepEqual(got, want) {
+		t.Errorf("want files %+v; got %+v", want, got)
+	}
+}
+
+func TestDiffFiles(t *testing.T) {
+	from := []*models.File{
+		{Filename: "Dockerfile", Content: "FROM alpine"},
+		{Filename: "docker-compose.yml", Content: "services:"},
+		{Filename: "run.sh", Content: "docker compose up"},
+	}
+	to := []*models.File{
+		{Filename: "Dockerfile", Content: "FROM alpine"},
+		{Filename: "Makefile", Content: "up:"},
+		{Filename: "run.sh", Content: "docker compose up -d"},
+	}
+
+	tests := []struct {
+		name     string
+		from, to []*models.File
+		want     []string
+	}{
+		{"Same files", from, from, []string{}},
+		{"First revision", nil, from, []string{"Dockerfile added", "docker-compose.yml added", "run.sh added"}},
+		{"Changed files", from, to, []string{"Makefile added", "run.sh changed", "docker-compose.yml removed"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, d := range diffFiles(tt.from, tt.to) {
+				state := "changed"
+				if d.Added {
+					state = "added"
+				} else if d.Removed {
+					state = "removed"
+				}
+				if len(d.Hunks) == 0 {
+					t.Errorf("want changes of %s; got none", d.Filename)
+				}
+				got = append(got, d.Filename+" "+state)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("want diffs %q; got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestShowRawSnippetFile(t *testing.T) {
+	app := newTestApplication(t)
+	insertTestUsers(t, app, "alice")
+	files := []*models.File{{Filename: "run.sh", Content: "docker compose up"}}
+	insertTestSnippet(t, app, &models.Snippet{Filename: "Dockerfile", Content: "FROM alpine", Files: files})
+	insertTestSnippet(t, app, &models.Snippet{Filename: "Dockerfile", Content: "FROM alpine", Files: files, Password: "pa55word123"})
+	insertTestSnippet(t, app, &models.Snippet{UserID: 1, Filename: "Dockerfile", Content: "FROM alpine", Files: files, BurnAfterReading: true})
+
+	ts := newTestServer(t, app.routes())
+	anonymous := ts.newClient(t)
+	owner := ts.newClient(t)
+	owner.login(t, "alice@example.com")
+
+	tests := []struct {
+		name     string
+		client   *testClient
+		urlPath  string
+		wantCode int
+		wantBody string
+	}{
+		{"First file", anonymous, "/snippets/1/raw/Dockerfile", http.StatusOK, "FROM alpine"},
+		{"Other file", anonymous, "/snippets/1/raw/run.sh", http.StatusOK, "docker compose up"},
+		{"Unknown file", anonymous, "/snippets/1/raw/run.bash", http.StatusNotFound, ""},
+		{"Locked snippet", anonymous, "/snippets/2/raw/run.sh", http.StatusForbidden, ""},
+		{"Unknown file of a locked snippet", anonymous, "/snippets/2/raw/run.bash", http.StatusForbidden, ""},
+		{"Unknown file of a burning snippet", anonymous, "/snippets/3/raw/run.bash", http.StatusNotFound, ""},
+		{"File of a burning snippet", anonymous, "/snippets/3/raw/run.sh", http.StatusNotFound, ""},
+		{"File of an own burning snippet", owner, "/snippets/3/raw/run.sh", http.StatusOK, "docker compose up"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := tt.client.get(t, tt.urlPath)
+			if code != tt.wantCode {
+				t.Errorf("want status %d; got %d", tt.wantCode, code)
+			}
+			if tt.wantBody != "" && body != tt.wantBody {
+				t.Errorf("want body %q; got %q", tt.wantBody, body)
+			}
+		})
+	}
+
+	if _, err := app.snippets.Peek(3); err != nil {
+		t.Errorf("want the burning snippet to be left unburned; got %v", err)
+	}
+}
+
+func TestShowRawSnippet(t *testing.T) {
+	app := newTestApplication(t)
+	insertTestSnippet(t, app, &models.Snippet{Title: "Single", Content: "echo hi"})
+	insertTestSnippet(t, app, &models.Snippet{Title: "Stack", Filename: "Dockerfile", Content: "FROM alpine\n",
+		Files: []*models.File{{Filename: "run.sh", Content: "docker compose up"}}})
+
+	ts := newTestServer(t, app.routes())
+	client := ts.newClient(t)
+
+	_, _, body := client.get(t, "/snippets/1/raw")
+	if body != "echo hi" {
+		t.Errorf("want the content of a single file; got %q", body)
+	}
+
+	_, _, body = client.get(t, "/snippets/2/raw")
+	want := "==> Dockerfile <==\nFROM alpine\n\n==> run.sh <==\ndocker compose up\n"
+	if body != want {
+		t.Errorf("want all files %q; got %q", want, body)
+	}
+}
+
+func TestDownloadSnippet(t *testing.T) {
+	app := newTestApplication(t)
+	insertTestSnippet(t, app, &models.Snippet{Title: "Single", Content: "echo hi", Language: "bash"})
+	insertTestSnippet(t, app, &models.Snippet{Title: "Stack", Filename: "Dockerfile", Content: "FROM alpine",
+		Files: []*models.File{{Filename: "run.sh", Content: "docker compose up"}}})
+
+	ts := newTestServer(t, app.routes())
+	client := ts.newClient(t)
+
+	_, header, body := client.get(t, "/snippets/1/download")
+	if got := header.Get("Content-Disposition"); got != "attachment; filename=single.sh" || body != "echo hi" {
+		t.Errorf("want single.sh with the content of the snippet; got %q and %q", got, body)
+	}
+
+	_, header, body = client.get(t, "/snippets/2/download")
+	if got := header.Get("Content-Disposition"); got != "attachment; filename=stack.zip" {
+		t.Errorf("want the attachment stack.zip; got %q", got)
+	}
+	if got := header.Get("Content-Type"); got != "application/zip" {
+		t.Errorf("want Content-Type application/zip; got %q", got)
+	}
+
+	zr, err := zip.NewReader(strings.NewReader(body), int64(len(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got[f.Name] = string(b)
+	}
+	want := map[string]string{"Dockerfile": "FROM alpine", "run.sh": "docker compose up"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want files %v in the archive; got %v", want, got)
+	}
+}
@@ -2,45 +2,278 @@ package main
 
 import (
     "errors"
-    "fmt"
+    "mime"
     "net/http"
+    "net/url"
     "strconv"
+    "strings"
+    "time"
 
     "github.com/go-zoo/bone"
     "jackson.software/snippetbox/pkg/forms"
     "jackson.software/snippetbox/pkg/models"
 )
 
+// MaxTags is the maximum number of tags of a snippet.
+const maxTags = 10
+
+// MinExpiry and maxExpiry are the shortest and longest durations after which
+// a snippet may expire, unless it never does.
+const (
+    minExpiry = 10 * time.Minute
+    maxExpiry = 10 * 365 * 24 * time.Hour
+)
+
+// ExpiresAtLayout is the layout of the date-time picker for the expiry time of
+// a snippet, which is taken as UTC.
+const expiresAtLayout = "2006-01-02T15:04"
+
+// Visibilities are the values permitted for the visibility of a snippet.
+var visibilities = []string{
+    string(models.VisibilityPublic),
+    string(models.VisibilityUnlisted),
+    string(models.VisibilityPrivate),
+}
+
+// TokenScopes are the values permitted for the scopes of an API token.
+var tokenScopes = []string{
+    models.ScopeSnippetsRead,
+    models.ScopeSnippetsWrite,
+}
+
 // Home handler shows the home page with the latest snippets.
 func (app *application) home(w http.ResponseWriter, r *http.Request) {
-    s, err := app.snippets.Latest()
+    sort, period := r.URL.Query().Get("sort"), r.URL.Query().Get("period")
+
+    var s []*models.Snippet
+    var err error
+    switch sort {
+    case "":
+        s, err = app.snippets.Latest()
+    case "stars":
+        if period == "" {
+            period = defaultStarPeriod
+        }
+        since, ok := starredSince(period, time.Now())
+        if !ok {
+            app.clientError(w, http.StatusBadRequest)
+            return
+        }
+        s, err = app.snippets.MostStarred(since, 10)
+    default:
+        app.clientError(w, http.StatusBadRequest)
+        return
+    }
     if err != nil {
         app.serverError(w, err)
         return
     }
 
-    app.render(w, r, "home.page.tmpl", &templateData{Snippets: s})
+    app.render(w, r, "home.page.tmpl", &templateData{Period: period, Snippets: s, Sort: sort})
 }
 
-// ShowSnippet handler shows a specific snippet.
+// ListSnippets handler shows all snippets, a page at a time, newest first.
+func (app *application) listSnippets(w http.ResponseWriter, r *http.Request) {
+    cursor, err := parseCursor(r.URL.Query())
+    if err != nil {
+        app.clientError(w, http.StatusBadRequest)
+        return
+    }
+
+    s, err := app.snippets.Page(cursor, snippetsPerPage+1)
+    if err != nil {
+        app.serverError(w, err)
+        return
+    }
+
+    s, p := paginate(cursor, s, snippetsPerPage)
+    app.render(w, r, "snippets.page.tmpl", &templateData{Pagination: p, Snippets: s})
+}
+
+// SearchSnippets handler shows the snippets matching the search query given
+// by the q parameter, most relevant first.
+func (app *application) searchSnippets(w http.ResponseWriter, r *http.Request) {
+    q := strings.TrimSpace(r.URL.Query().Get("q"))
+    if q == "" {
+        app.render(w, r, "search.page.tmpl", &templateData{})
+        return
+    }
+
+    s, err := app.snippets.Search(q, maxSearchResults)
+    if err != nil {
+        app.serverError(w, err)
+        return
+    }
+
+    app.render(w, r, "search.page.tmpl", &templateData{Query: q, Snippets: s})
+}
+
+// ShowSnippet handler shows a specific snippet. A snippet protected by a
+// password is only shown once it has been unlocked, so a form asking for the
+// password is shown in its place. A snippet which burns after reading is
+// burned when it's shown to anybody but its owner, and a page telling it has
+// been destroyed is shown from then on.
 func (app *application) showSnippet(w http.ResponseWriter, r *http.Request) {
-    id, err := strconv.Atoi(bone.GetValue(r, "id"))
-    if err != nil || id < 1 {
-        app.notFound(w)
+    s, err := app.readSnippet(r)
+    if err != nil {
+        if errors.Is(err, errLocked) {
+            app.render(w, r, "unlock.page.tmpl", &templateData{Snippet: s, Form: forms.New(nil)})
+        } else if errors.Is(err, models.ErrNoRecord) {
+            app.notFound(w)
+        } else if errors.Is(err, models.ErrBurned) {
+            app.renderStatus(w, r, http.StatusGone, "burned.page.tmpl", nil)
+        } else {
+            app.serverError(w, err)
+        }
         return
     }
 
-    s, err := app.snippets.Get(id)
+    app.renderSnippet(w, r, s, forms.New(nil))
+}
+
+// RenderSnippet renders the page of the given snippet, which has already been
+// read, along with its provenance, its comments and the given comment form.
+func (app *application) renderSnippet(w http.ResponseWriter, r *http.Request, s *models.Snippet, form *forms.Form) {
+    forkedFrom, forks, err := app.snippetProvenance(r, s)
     if err != nil {
-        if errors.Is(err, models.ErrNoRecord) {
+        app.serverError(w, err)
+        return
+    }
+
+    comments, err := app.comments.ForSnippet(s.ID)
+    if err != nil {
+        app.serverError(w, err)
+        return
+    }
+
+    lines, comments, err := snippetLines(s, comments)
+    if err != nil {
+        app.serverError(w, err)
+        return
+    }
+
+    starred := false
+    if app.isAuthenticated(r) {
+        starred, err = app.stars.Exists(s.ID, app.authenticatedUserID(r))
+        if err != nil {
+            app.serverError(w, err)
+            return
+        }
+    }
+
+    app.render(w, r, "show.page.tmpl", &templateData{
+        Comments:   comments,
+        ForkedFrom: forkedFrom,
+        Forks:      forks,
+        Form:       form,
+        Lines:      lines,
+        Snippet:    s,
+        Starred:    starred,
+    })
+}
+
+// ShowRawSnippet handler sends the content of a snippet as plain text, without
+// any layout, following the same rules as showSnippet. The files of a snippet
+// made of several files are sent one after the other, each headed by its
+// filename.
+func (app *application) showRawSnippet(w http.ResponseWriter, r *http.Request) {
+    s, ok := app.rawSnippet(w, r)
+    if !ok {
+        return
+    }
+
+    if len(s.Files) > 0 {
+        writeRawFiles(w, s.AllFiles())
+        return
+    }
+
+    w.Write([]byte(s.Content))
+}
+
+// DownloadSnippet handler sends the content of a snippet as plain text just
+// like showRawSnippet, but as an attachment named after its title and language.
+// A snippet made of several files is sent as a zip archive of its files.
+func (app *application) downloadSnippet(w http.ResponseWriter, r *http.Request) {
+    s, ok := app.rawSnippet(w, r)
+    if !ok {
+        return
+    }
+
+    content := []byte(s.Content)
+    if len(s.Files) > 0 {
+        var err error
+        content, err = zipFiles(s.AllFiles(), s.Created)
+        if err != nil {
+            app.serverError(w, err)
+            return
+        }
+        w.Header().Set("Content-Type", "application/zip")
+    }
+
+    disposition := mime.FormatMediaType("attachment", map[string]string{"filename": snippetFilename(s)})
+    w.Header().Set("Content-Disposition", disposition)
+    w.Write(content)
+}
+
+// RawSnippet reads the snippet for showRawSnippet and downloadSnippet and sets
+// the plain text headers. If the snippet can't be read, a plain text error is
+// sent instead: 403 if it's locked and 410 if it has been burned.
+func (app *application) rawSnippet(w http.ResponseWriter, r *http.Request) (*models.Snippet, bool) {
+    s, err := app.readSnippet(r)
+    if err != nil {
+        if errors.Is(err, errLocked) {
+            app.clientError(w, http.StatusForbidden)
+        } else if errors.Is(err, models.ErrNoRecord) {
             app.notFound(w)
+        } else if errors.Is(err, models.ErrBurned) {
+            app.clientError(w, http.StatusGone)
         } else {
             app.serverError(w, err)
         }
+        return nil, false
+    }
+
+    w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+    w.Header().Set("X-Content-Type-Options", "nosniff")
+    return s, true
+}
+
+// UnlockSnippet handler unlocks a snippet protected by a password for the
+// rest of the session if the given password is correct.
+func (app *application) unlockSnippet(w http.ResponseWriter, r *http.Request) {
+    s, err := app.snippetFromPath(r)
+    if err != nil {
+        if errors.Is(err, models.ErrNoRecord) || errors.Is(err, models.ErrBurned) {
+            app.notFound(w)
+        } else {
+            app.serverError(w, err)
+        }
+        return
+    }
+
+    err = r.ParseForm()
+    if err != nil {
+        app.clientError(w, http.StatusBadRequest)
         return
     }
 
-    app.render(w, r, "show.page.tmpl", &templateData{Snippet: s})
+    form := forms.New(r.PostForm)
+    err = app.snippets.Unlock(s.ID, form.Get("password"))
+    if err != nil {
+        if errors.Is(err, models.ErrInvalidCredentials) {
+            form.Errors.Add("generic", "Password is incorrect")
+            app.render(w, r, "unlock.page.tmpl", &templateData{Snippet: s, Form: form})
+        } else if errors.Is(err, models.ErrNoRecord) {
+            // The snippet isn't protected (anymore), so there's nothing to unlock.
+            http.Redirect(w, r, s.Path(), http.StatusSeeOther)
+        } else {
+            app.serverError(w, err)
+        }
+        return
+    }
+
+    app.session.Put(r, unlockedSnippetKey(s.ID), true)
+    http.Redirect(w, r, s.Path(), http.StatusSeeOther)
 }
 
 // ShowSnippetForm handler shows a form with fields to create a new snippet.
@@ -50,7 +283,36 @@ func (app *application) showSnippetForm(w http.ResponseWriter, r *http.Request)
     })
 }
 
-// CreateSnippet handler creates a new snippet.
+// ForkSnippetForm handler shows the form to create a snippet, pre-filled with
+// the title, files, visibility, language and tags of the snippet to fork.
+func (app *application) forkSnippetForm(w http.ResponseWriter, r *http.Request) {
+    s, err := app.forkableSnippet(r, bone.GetValue(r, "id"))
+    if err != nil {
+        if errors.Is(err, errNotForkable) {
+            app.clientError(w, http.StatusForbidden)
+        } else if errors.Is(err, models.ErrNoRecord) || errors.Is(err, models.ErrBurned) {
+            app.notFound(w)
+        } else {
+            app.serverError(w, err)
+        }
+        return
+    }
+
+    values := url.Values{
+        "title":       {s.Title},
+        "content":     {s.Content},
+        "visibility":  {string(s.Visibility)},
+        "language":    {s.Language},
+        "tags":        {strings.Join(s.Tags, ", ")},
+        "forked_from": {s.Ref()},
+    }
+    setFileValues(values, s)
+
+    app.render(w, r, "create.page.tmpl", &templateData{ForkedFrom: s, Form: forms.New(values)})
+}
+
+// CreateSnippet handler creates a new snippet. If the forked_from field holds
+// the reference of another snippet, the new snippet is a fork of it.
 func (app *application) createSnippet(w http.ResponseWriter, r *http.Request) {
     err := r.ParseForm()
     if err != nil {
@@ -59,16 +321,30 @@ func (app *application) createSnippet(w http.ResponseWriter, r *http.Request) {
     }
 
     form := forms.New(r.PostForm)
-    form.Required("title", "content", "expires")
-    form.MaxLength("title", 100)
-    form.PermittedValues("expires", "365", "7", "1")
+    validateNewSnippet(form)
+
+    var forkedFrom *models.Snippet
+    if ref := form.Get("forked_from"); ref != "" {
+        forkedFrom, err = app.forkableSnippet(r, ref)
+        if errors.Is(err, errNotForkable) || errors.Is(err, models.ErrNoRecord) || errors.Is(err, models.ErrBurned) {
+            form.Errors.Add("forked_from", "The snippet to fork is no longer available")
+        } else if err != nil {
+            app.serverError(w, err)
+            return
+        }
+    }
 
     if !form.Valid() {
-        app.render(w, r, "create.page.tmpl", &templateData{Form: form})
+        app.render(w, r, "create.page.tmpl", &templateData{ForkedFrom: forkedFrom, Form: form})
         return
     }
 
-    id, err := app.snippets.Insert(form.Get("title"), form.Get("content"), form.Get("expires"))
+    forkedFromID := 0
+    if forkedFrom != nil {
+        forkedFromID = forkedFrom.ID
+    }
+
+    s, err := app.insertSnippet(r, form, forkedFromID)
     if err != nil {
         app.serverError(w, err)
         return
@@ -76,7 +352,266 @@ func (app *application) createSnippet(w http.ResponseWriter, r *http.Request) {
 
     app.session.Put(r, "flash", "Your Snippet was successfully created")
 
-    http.Redirect(w, r, fmt.Sprintf("/snippets/%d", id), http.StatusSeeOther)
+    http.Redirect(w, r, s.Path(), http.StatusSeeOther)
+}
+
+// EditSnippetForm handler shows a form, pre-filled with the current title,
+// files, visibility, language and tags, to edit a snippet.
+func (app *application) editSnippetForm(w http.ResponseWriter, r *http.Request) {
+    s := app.snippetFromContext(r)
+
+    values := url.Values{
+        "title":      {s.Title},
+        "content":    {s.Content},
+        "visibility": {string(s.Visibility)},
+        "language":   {s.Language},
+        "tags":       {strings.Join(s.Tags, ", ")},
+    }
+    setFileValues(values, s)
+
+    app.render(w, r, "edit.page.tmpl", &templateData{Snippet: s, Form: forms.New(values)})
+}
+
+// EditSnippet handler updates the title, files, visibility, language and tags
+// of a snippet. Snippets which stop being public get a slug, which they keep
+// from then on. The password of a snippet is kept unless a new one is given or
+// it is removed.
+func (app *application) editSnippet(w http.ResponseWriter, r *http.Request) {
+    s := app.snippetFromContext(r)
+
+    err := r.ParseForm()
+    if err != nil {
+        app.clientError(w, http.StatusBadRequest)
+        return
+    }
+
+    form := forms.New(r.PostForm)
+    validateSnippet(form)
+
+    if !form.Valid() {
+        app.render(w, r, "edit.page.tmpl", &templateData{Snippet: s, Form: form})
+        return
+    }
+
+    err = app.updateSnippet(s, form)
+    if err != nil {
+        app.serverError(w, err)
+        return
+    }
+
+    app.session.Put(r, "flash", "Your Snippet was successfully updated")
+
+    http.Redirect(w, r, s.Path(), http.StatusSeeOther)
+}
+
+// DeleteSnippet handler deletes a snippet.
+func (app *application) deleteSnippet(w http.ResponseWriter, r *http.Request) {
+    s := app.snippetFromContext(r)
+
+    err := app.snippets.Delete(s.ID)
+    if err != nil {
+        if errors.Is(err, models.ErrNoRecord) {
+            app.notFound(w)
+        } else {
+            app.serverError(w, err)
+        }
+        return
+    }
+
+    app.session.Put(r, "flash", "Your Snippet was successfully deleted")
+
+    http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// ShowRevisions handler shows all saved revisions of a snippet.
+func (app *application) showRevisions(w http.ResponseWriter, r *http.Request) {
+    s, err := app.snippetFromPath(r)
+    if err != nil {
+        if errors.Is(err, models.ErrNoRecord) || errors.Is(err, models.ErrBurned) {
+            app.notFound(w)
+        } else {
+            app.serverError(w, err)
+        }
+        return
+    }
+
+    // The revisions would give away the content of a snippet which burns
+    // after reading without burning it.
+    if s.BurnAfterReading && !app.isSnippetOwner(r, s) {
+        app.notFound(w)
+        return
+    }
+
+    if !app.isUnlocked(r, s) {
+        http.Redirect(w, r, s.Path(), http.StatusSeeOther)
+        return
+    }
+
+    revisions, err := app.revisions.ForSnippet(s.ID)
+    if err != nil {
+        app.serverError(w, err)
+        return
+    }
+
+    app.render(w, r, "revisions.page.tmpl", &templateData{Snippet: s, Revisions: revisions})
+}
+
+// ShowRevisionDiff handler shows a unified diff of every file which changed
+// between the two revisions of a snippet given by the from and to query
+// parameters. If from is omitted, the revision is compared with the one saved
+// right before it.
+func (app *application) showRevisionDiff(w http.ResponseWriter, r *http.Request) {
+    toID, err := strconv.Atoi(r.URL.Query().Get("to"))
+    if err != nil || toID < 1 {
+        app.clientError(w, http.StatusBadRequest)
+        return
+    }
+
+    s, err := app.snippetFromPath(r)
+    if err != nil {
+        if errors.Is(err, models.ErrNoRecord) || errors.Is(err, models.ErrBurned) {
+            app.notFound(w)
+        } else {
+            app.serverError(w, err)
+        }
+        return
+    }
+
+    // The revisions would give away the content of a snippet which burns
+    // after reading without burning it.
+    if s.BurnAfterReading && !app.isSnippetOwner(r, s) {
+        app.notFound(w)
+        return
+    }
+
+    if !app.isUnlocked(r, s) {
+        http.Redirect(w, r, s.Path(), http.StatusSeeOther)
+        return
+    }
+
+    revisions, err := app.revisions.ForSnippet(s.ID)
+    if err != nil {
+        app.serverError(w, err)
+        return
+    }
+
+    // Revisions are ordered newest first, so the previous revision of the
+    // target is the one following it. The first revision is compared to nothing.
+    var from, to *models.Revision
+    for i, rev := range revisions {
+        if rev.ID == toID {
+            to = rev
+            if i+1 < len(revisions) {
+                from = revisions[i+1]
+            }
+        }
+    }
+    if to == nil {
+        app.notFound(w)
+        return
+    }
+
+    if q := r.URL.Query().Get("from"); q != "" {
+        fromID, err := strconv.Atoi(q)
+        if err != nil || fromID < 1 {
+            app.clientError(w, http.StatusBadRequest)
+            return
+        }
+
+        from, err = app.revisions.Get(s.ID, fromID)
+        if err != nil {
+            if errors.Is(err, models.ErrNoRecord) {
+                app.notFound(w)
+            } else {
+                app.serverError(w, err)
+            }
+            return
+        }
+    }
+
+    var old []*models.File
+    if from != nil {
+        old = from.AllFiles()
+    }
+
+    app.render(w, r, "diff.page.tmpl", &templateData{
+        Snippet:      s,
+        FromRevision: from,
+        ToRevision:   to,
+        Diff:         diffFiles(old, to.AllFiles()),
+    })
+}
+
+// ShowUserSnippets handler shows all snippets created by a specific user.
+func (app *application) showUserSnippets(w http.ResponseWriter, r *http.Request) {
+    id, err := strconv.Atoi(bone.GetValue(r, "id"))
+    if err != nil || id < 1 {
+        app.notFound(w)
+        return
+    }
+
+    u, err := app.users.Get(id)
+    if err != nil {
+        if errors.Is(err, models.ErrNoRecord) {
+            app.notFound(w)
+        } else {
+            app.serverError(w, err)
+        }
+        return
+    }
+
+    s, err := app.snippets.ForUser(u.ID)
+    if err != nil {
+        app.serverError(w, err)
+        return
+    }
+
+    // Snippets which aren't public are only listed for the user who created them.
+    if u.ID != app.authenticatedUserID(r) {
+        public := []*models.Snippet{}
+        for _, snippet := range s {
+            if snippet.Visibility == models.VisibilityPublic {
+                public = append(public, snippet)
+            }
+        }
+        s = public
+    }
+
+    c, err := app.collections.ForUser(u.ID)
+    if err != nil {
+        app.serverError(w, err)
+        return
+    }
+
+    // The same goes for collections.
+    if u.ID != app.authenticatedUserID(r) {
+        public := []*models.Collection{}
+        for _, collection := range c {
+            if collection.Visibility == models.VisibilityPublic {
+                public = append(public, collection)
+            }
+        }
+        c = public
+    }
+
+    app.render(w, r, "user.page.tmpl", &templateData{User: u, Snippets: s, Collections: c})
+}
+
+// ShowTagSnippets handler shows all snippets tagged with a specific tag.
+func (app *application) showTagSnippets(w http.ResponseWriter, r *http.Request) {
+    tag := bone.GetValue(r, "tag")
+    if !forms.TagRX.MatchString(tag) {
+        app.notFound(w)
+        return
+    }
+
+    s, err := app.snippets.ForTag(tag)
+    if err != nil {
+        app.serverError(w, err)
+        return
+    }
+
+    app.render(w, r, "tag.page.tmpl", &templateData{Snippets: s, Tag: tag})
 }
 
 func (app *application) signupUserForm(w http.ResponseWriter, r *http.Request) {
@@ -160,3 +695,97 @@ func (app *application) logoutUser(w http.ResponseWriter, r *http.Request) {
 
     http.Redirect(w, r, "/", http.StatusSeeOther)
 }
+
+// ShowTokens handler shows the API tokens of the authenticated user along with
+// a form to create another one.
+func (app *application) showTokens(w http.ResponseWriter, r *http.Request) {
+    app.renderTokens(w, r, forms.New(url.Values{
+        "scopes":  tokenScopes,
+        "expires": {"8760h"},
+    }))
+}
+
+// CreateToken handler creates an API token for the authenticated user. Since
+// only its hash is stored, the token is shown in plaintext just once, on the
+// tokens page the user is redirected to.
+func (app *application) createToken(w http.ResponseWriter, r *http.Request) {
+    err := r.ParseForm()
+    if err != nil {
+        app.clientError(w, http.StatusBadRequest)
+        return
+    }
+
+    form := forms.New(r.PostForm)
+    form.Required("name", "scopes", "expires")
+    form.MaxLength("name", 100)
+    form.PermittedMultipleValues("scopes", tokenScopes...)
+    if form.Get("expires") != "never" {
+        form.Duration("expires", minExpiry, maxExpiry)
+    }
+
+    if !form.Valid() {
+        app.renderTokens(w, r, form)
+        return
+    }
+
+    t := &models.Token{
+        UserID: app.authenticatedUserID(r),
+        Name:   form.Get("name"),
+        Scopes: form.Values["scopes"],
+    }
+    if form.Get("expires") != "never" {
+        d, _ := time.ParseDuration(form.Get("expires"))
+        t.Expires = time.Now().UTC().Add(d).Truncate(time.Second)
+    }
+
+    token, err := app.tokens.Insert(t)
+    if err != nil {
+        app.serverError(w, err)
+        return
+    }
+
+    app.session.Put(r, "newToken", token)
+    app.session.Put(r, "flash", "Your token was successfully created")
+
+    http.Redirect(w, r, "/account/tokens", http.StatusSeeOther)
+}
+
+// DeleteToken handler revokes an API token of the authenticated user.
+func (app *application) deleteToken(w http.ResponseWriter, r *http.Request) {
+    id, err := strconv.Atoi(bone.GetValue(r, "id"))
+    if err != nil || id < 1 {
+        app.notFound(w)
+        return
+    }
+
+    err = app.tokens.Delete(app.authenticatedUserID(r), id)
+    if err != nil {
+        if errors.Is(err, models.ErrNoRecord) {
+            app.notFound(w)
+        } else {
+            app.serverError(w, err)
+        }
+        return
+    }
+
+    app.session.Put(r, "flash", "Your token was successfully revoked")
+
+    http.Redirect(w, r, "/account/tokens", http.StatusSeeOther)
+}
+
+// RenderTokens renders the tokens page of the authenticated user with the
+// given form for creating a token, along with the token which has just been
+// created, if any.
+func (app *application) renderTokens(w http.ResponseWriter, r *http.Request, form *forms.Form) {
+    tokens, err := app.tokens.ForUser(app.authenticatedUserID(r))
+    if err != nil {
+        app.serverError(w, err)
+        return
+    }
+
+    app.render(w, r, "tokens.page.tmpl", &templateData{
+        Form:     form,
+        NewToken: app.session.PopString(r, "newToken"),
+        Tokens:   tokens,
+    })
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"jackson.software/snippetbox/pkg/models"
+)
+
+func TestShowSnippetVisibility(t *testing.T) {
+	app := newTestApplication(t)
+	insertTestUsers(t, app, "alice", "bob")
+	insertTestSnippet(t, app, &models.Snippet{UserID: 1, Title: "Public snippet", Visibility: models.VisibilityPublic})
+	insertTestSnippet(t, app, &models.Snippet{UserID: 1, Title: "Unlisted snippet", Visibility: models.VisibilityUnlisted, Slug: "unlistedSlug"})
+	insertTestSnippet(t, app, &models.Snippet{UserID: 1, Title: "Private snippet", Visibility: models.VisibilityPrivate, Slug: "privateSlug"})
+
+	ts := newTestServer(t, app.routes())
+	anonymous := ts.newClient(t)
+	alice := ts.newClient(t)
+	alice.login(t, "alice@example.com")
+	bob := ts.newClient(t)
+	bob.login(t, "bob@example.com")
+
+	tests := []struct {
+		name     string
+		client   *testClient
+		urlPath  string
+		wantCode int
+	}{
+		{"Public by ID", anonymous, "/snippets/1", http.StatusOK},
+		{"Unlisted by ID", anonymous, "/snippets/2", http.StatusNotFound},
+		{"Unlisted by slug", anonymous, "/snippets/unlistedSlug", http.StatusOK},
+		{"Raw unlisted by ID", anonymous, "/snippets/2/raw", http.StatusNotFound},
+		{"Private by ID", anonymous, "/snippets/3", http.StatusNotFound},
+		{"Private by slug", anonymous, "/snippets/privateSlug", http.StatusNotFound},
+		{"Raw private by slug", anonymous, "/snippets/privateSlug/raw", http.StatusNotFound},
+		{"Private of another user", bob, "/snippets/privateSlug", http.StatusNotFound},
+		{"Own private by ID", alice, "/snippets/3", http.StatusNotFound},
+		{"Own private by slug", alice, "/snippets/privateSlug", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, _ := tt.client.get(t, tt.urlPath)
+			if code != tt.wantCode {
+				t.Errorf("want status %d; got %d", tt.wantCode, code)
+			}
+		})
+	}
+
+	for _, urlPath := range []string{"/", "/snippets", "/users/1/snippets", "/search?q=echo"} {
+		code, _, body := bob.get(t, urlPath)
+		if code != http.StatusOK {
+			t.Errorf("want status %d for %s; got %d", http.StatusOK, urlPath, code)
+		}
+		if !strings.Contains(body, "Public snippet") || strings.Contains(body, "Unlisted snippet") || strings.Contains(body, "Private snippet") {
+			t.Errorf("want only public snippets listed on %s", urlPath)
+		}
+	}
+}
+
+func TestUnlockSnippet(t *testing.T) {
+	app := newTestApplication(t)
+	insertTestUsers(t, app, "alice", "bob")
+	insertTestSnippet(t, app, &models.Snippet{UserID: 1, Content: "secret content", Password: "hunter22"})
+
+	ts := newTestServer(t, app.routes())
+	alice := ts.newClient(t)
+	alice.login(t, "alice@example.com")
+	bob := ts.newClient(t)
+	bob.login(t, "bob@example.com")
+	anonymous := ts.newClient(t)
+
+	// AssertLocked checks whether the snippet is hidden from the given client,
+	// which is asked for the password instead, or shown to it.
+	assertLocked := func(t *testing.T, c *testClient, locked bool) {
+		t.Helper()
+		code, _, body := c.get(t, "/snippets/1")
+		if code != http.StatusOK {
+			t.Fatalf("want status %d; got %d", http.StatusOK, code)
+		}
+		if strings.Contains(body, "/snippets/1/unlock") != locked || strings.Contains(body, "secret content") == locked {
+			t.Errorf("want the snippet to be locked: %t; got %s", locked, body)
+		}
+
+		wantCode := http.StatusOK
+		if locked {
+			wantCode = http.StatusForbidden
+		}
+		if code, _, _ := c.get(t, "/snippets/1/raw"); code != wantCode {
+			t.Errorf("want status %d for the raw snippet; got %d", wantCode, code)
+		}
+	}
+
+	assertLocked(t, alice, false)
+	assertLocked(t, bob, true)
+	assertLocked(t, anonymous, true)
+
+	code, header, _ := bob.get(t, "/snippets/1/revisions")
+	if code != http.StatusSeeOther || header.Get("Location") != "/snippets/1" {
+		t.Errorf("want the revisions of a locked snippet to redirect to its unlock form; got %d and %q", code, header.Get("Location"))
+	}
+
+	anonymous.get(t, "/snippets/1")
+	code, _, body := anonymous.postForm(t, "/snippets/1/unlock", url.Values{"password": {"wrong password"}})
+	if code != http.StatusOK || !strings.Contains(body, "Password is incorrect") {
+		t.Errorf("want the unlock form with an error for a wrong password; got %d", code)
+	}
+	assertLocked(t, anonymous, true)
+
+	code, header, _ = anonymous.postForm(t, "/snippets/1/unlock", url.Values{"password": {"hunter22"}})
+	if code != http.StatusSeeOther || header.Get("Location") != "/snippets/1" {
+		t.Errorf("want a redirect to the snippet after unlocking it; got %d and %q", code, header.Get("Location"))
+	}
+	assertLocked(t, anonymous, false)
+	assertLocked(t, bob, true)
+}
@@ -2,12 +2,20 @@ package main
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"net/http"
 	"runtime/debug"
+	"strconv"
+	"strings"
 	"time"
+	"unicode"
 
+	"github.com/go-zoo/bone"
 	"github.com/justinas/nosurf"
+	"jackson.software/snippetbox/pkg/forms"
+	"jackson.software/snippetbox/pkg/highlight"
+	"jackson.software/snippetbox/pkg/models"
 )
 
 // Server error writes an error message and stack trace to the error log
@@ -43,6 +51,7 @@ func (app *application) addDefaultData(td *templateData, r *http.Request) *templ
 	td.CurrentYear = time.Now().Year()
 	td.Flash = app.session.PopString(r, "flash")
 	td.IsAuthenticated = app.isAuthenticated(r)
+	td.AuthenticatedUserID = app.authenticatedUserID(r)
 
 	return td
 }
@@ -52,6 +61,13 @@ func (app *application) addDefaultData(td *templateData, r *http.Request) *templ
 //
 // Render returns an error if no template could be found with the given name.
 func (app *application) render(w http.ResponseWriter, r *http.Request, name string, td *templateData) {
+	app.renderStatus(w, r, http.StatusOK, name, td)
+}
+
+// RenderStatus renders a template just like render, but sends it with the
+// given status code. The status code is only written once the template has
+// been rendered, so a template runtime error still results in a server error.
+func (app *application) renderStatus(w http.ResponseWriter, r *http.Request, status int, name string, td *templateData) {
 	ts, ok := app.templateCache[name]
 	if !ok {
 		app.serverError(w, fmt.Errorf("template %s does not exist", name))
@@ -64,8 +80,10 @@ func (app *application) render(w http.ResponseWriter, r *http.Request, name stri
 	err := ts.Execute(buf, app.addDefaultData(td, r))
 	if err != nil {
 		app.serverError(w, err)
+		return
 	}
 
+	w.WriteHeader(status)
 	buf.WriteTo(w)
 }
 
@@ -78,3 +96,323 @@ func (app *application) isAuthenticated(r *http.Request) bool {
 
     return isAuthenticated
 }
+
+// AuthenticatedUserID returns the ID of the user who made the request, or 0
+// if the request was not made by an authenticated user.
+func (app *application) authenticatedUserID(r *http.Request) int {
+    if !app.isAuthenticated(r) {
+        return 0
+    }
+
+    userID, ok := r.Context().Value(contextKeyAuthenticatedUserID).(int)
+    if !ok {
+        return 0
+    }
+
+    return userID
+}
+
+// IsSnippetOwner returns true if the request was made by the user who created
+// the given snippet.
+func (app *application) isSnippetOwner(r *http.Request, s *models.Snippet) bool {
+    userID := app.authenticatedUserID(r)
+
+    return userID != 0 && s.UserID == userID
+}
+
+// IsUnlocked returns true if the given snippet may be shown to the user who
+// made the request: it isn't protected by a password, it's their own, or they
+// have entered its password during this session.
+func (app *application) isUnlocked(r *http.Request, s *models.Snippet) bool {
+    return !s.Protected || app.isSnippetOwner(r, s) || app.session.GetBool(r, unlockedSnippetKey(s.ID))
+}
+
+// UnlockedSnippetKey returns the session key which marks the snippet with the
+// given ID as unlocked.
+func unlockedSnippetKey(id int) string {
+    return fmt.Sprintf("unlockedSnippet:%d", id)
+}
+
+// SnippetFromContext returns the snippet which has been added to the request
+// context by the requireSnippetOwner middleware.
+func (app *application) snippetFromContext(r *http.Request) *models.Snippet {
+    s, ok := r.Context().Value(contextKeySnippet).(*models.Snippet)
+    if !ok {
+        return nil
+    }
+
+    return s
+}
+
+// TokenFromContext returns the API token which has been added to the request
+// context by the authenticateToken middleware, or nil if the request wasn't
+// authenticated by a token.
+func (app *application) tokenFromContext(r *http.Request) *models.Token {
+    t, ok := r.Context().Value(contextKeyToken).(*models.Token)
+    if !ok {
+        return nil
+    }
+
+    return t
+}
+
+// ValidateSnippet checks the fields of a form editing a snippet, which are the
+// ones every snippet form shares, along with its files. Tags are lower cased
+// before they're checked.
+func validateSnippet(form *forms.Form) {
+    form.Set("tags", strings.ToLower(form.Get("tags")))
+    form.Required("title", "content")
+    form.MaxLength("title", 100)
+    form.MaxItems("tags", maxTags)
+    form.ItemsMatchPattern("tags", forms.TagRX)
+    form.PermittedValues("language", highlight.IDs()...)
+    form.PermittedValues("visibility", visibilities...)
+    form.MinLength("password", 10)
+    validateFiles(form)
+}
+
+// ValidateNewSnippet checks the fields of a form creating a snippet, which
+// are the ones checked by validateSnippet along with the expiry time and
+// whether the snippet burns after reading.
+func validateNewSnippet(form *forms.Form) {
+    validateSnippet(form)
+    form.Required("expires")
+    form.PermittedValues("burn_after_reading", "true")
+    switch form.Get("expires") {
+    case "never":
+    case "date":
+        form.Required("expires_at")
+        form.FutureTime("expires_at", expiresAtLayout, maxExpiry)
+    default:
+        form.Duration("expires", minExpiry, maxExpiry)
+    }
+}
+
+// InsertSnippet creates a snippet owned by the authenticated user from the
+// given valid form and returns it. Snippets which aren't public get a slug.
+// The snippet is a fork of the snippet with the given ID, unless it's 0.
+func (app *application) insertSnippet(r *http.Request, form *forms.Form, forkedFromID int) (*models.Snippet, error) {
+    s := &models.Snippet{
+        UserID:           app.authenticatedUserID(r),
+        Title:            form.Get("title"),
+        Content:          form.Get("content"),
+        Visibility:       snippetVisibility(form),
+        BurnAfterReading: form.Get("burn_after_reading") == "true",
+        Password:         form.Get("password"),
+        Language:         snippetLanguage(form),
+        Filename:         strings.TrimSpace(form.Get("filename")),
+        Files:            snippetFiles(form),
+        Tags:             form.List("tags"),
+        Expires:          snippetExpires(form),
+        ForkedFromID:     forkedFromID,
+    }
+
+    var err error
+    if s.Visibility != models.VisibilityPublic {
+        s.Slug, err = models.NewSlug()
+        if err != nil {
+            return nil, err
+        }
+    }
+
+    s.ID, err = app.snippets.Insert(s)
+    if err != nil {
+        return nil, err
+    }
+
+    return s, nil
+}
+
+// UpdateSnippet updates the given snippet from the given valid form. Snippets
+// which stop being public get a slug, which they keep from then on. The
+// password is kept unless a new one is given or remove_password is "true".
+func (app *application) updateSnippet(s *models.Snippet, form *forms.Form) error {
+    s.Title = form.Get("title")
+    s.Content = form.Get("content")
+    s.Visibility = snippetVisibility(form)
+    s.Language = snippetLanguage(form)
+    s.Filename = strings.TrimSpace(form.Get("filename"))
+    s.Files = snippetFiles(form)
+    s.Tags = form.List("tags")
+    s.Password = form.Get("password")
+    s.Protected = s.Password != "" || s.Protected && form.Get("remove_password") != "true"
+
+    var err error
+    if s.Visibility != models.VisibilityPublic && s.Slug == "" {
+        s.Slug, err = models.NewSlug()
+        if err != nil {
+            return err
+        }
+    }
+
+    return app.snippets.Update(s)
+}
+
+// SnippetLanguage returns the language of the first file chosen in the given
+// snippet form, or the one detected from its filename and content if none has
+// been chosen.
+func snippetLanguage(form *forms.Form) string {
+    if language := form.Get("language"); language != "" {
+        return language
+    }
+
+    return highlight.DetectFile(strings.TrimSpace(form.Get("filename")), form.Get("content"))
+}
+
+// SnippetExpires returns the expiry time chosen in the given snippet form,
+// which is either a duration from now or a date, or the zero time if the
+// snippet never expires.
+func snippetExpires(form *forms.Form) time.Time {
+    switch form.Get("expires") {
+    case "never":
+        return time.Time{}
+    case "date":
+        t, _ := time.Parse(expiresAtLayout, form.Get("expires_at"))
+        return t
+    default:
+        d, _ := time.ParseDuration(form.Get("expires"))
+        return time.Now().UTC().Add(d).Truncate(time.Second)
+    }
+}
+
+// SnippetVisibility returns the visibility chosen in the given snippet form,
+// which is public unless chosen otherwise.
+func snippetVisibility(form *forms.Form) models.Visibility {
+    if visibility := form.Get("visibility"); visibility != "" {
+        return models.Visibility(visibility)
+    }
+
+    return models.VisibilityPublic
+}
+
+// SnippetFromPath loads the snippet referenced by the id route parameter, just
+// like snippetFromRef does.
+func (app *application) snippetFromPath(r *http.Request) (*models.Snippet, error) {
+    return app.snippetFromRef(r, bone.GetValue(r, "id"))
+}
+
+// SnippetFromRef loads the snippet with the given reference, which is the ID
+// of a public snippet or the slug of any snippet. Snippets which aren't public
+// can't be found by their ID, and private snippets can only be found by their
+// owner. In both cases models.ErrNoRecord is returned, just as if the snippet
+// didn't exist.
+//
+// Snippets which burn after reading are never burned by snippetFromRef, but
+// models.ErrBurned is returned for those which have been burned already.
+func (app *application) snippetFromRef(r *http.Request, ref string) (*models.Snippet, error) {
+    var s *models.Snippet
+    id, err := strconv.Atoi(ref)
+    if err != nil {
+        s, err = app.snippets.PeekBySlug(ref)
+    } else if id < 1 {
+        err = models.ErrNoRecord
+    } else if s, err = app.snippets.Peek(id); err == nil && s.Visibility != models.VisibilityPublic {
+        err = models.ErrNoRecord
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    if s.Visibility == models.VisibilityPrivate && !app.isSnippetOwner(r, s) {
+        return nil, models.ErrNoRecord
+    }
+
+    return s, nil
+}
+
+// ErrLocked is returned by readSnippet for snippets protected by a password
+// which haven't been unlocked yet.
+var errLocked = errors.New("main: snippet is locked")
+
+// ReadSnippet loads the snippet referenced by the id route parameter to be
+// read, just like snippetFromPath does, and burns it if it burns after reading
+// and the reader isn't its owner. If the snippet is protected by a password and
+// hasn't been unlocked, it's returned unburned along with errLocked.
+func (app *application) readSnippet(r *http.Request) (*models.Snippet, error) {
+    s, err := app.snippetFromPath(r)
+    if err != nil {
+        return nil, err
+    }
+    if !app.isUnlocked(r, s) {
+        return s, errLocked
+    }
+    if s.BurnAfterReading && !app.isSnippetOwner(r, s) {
+        return app.snippets.Get(s.ID)
+    }
+
+    return s, nil
+}
+
+// ErrNotForkable is returned by forkableSnippet for snippets which may be read
+// but not forked.
+var errNotForkable = errors.New("main: snippet can't be forked")
+
+// ForkableSnippet loads the snippet with the given reference, just like
+// snippetFromRef does, to be forked by the user who made the request. Snippets
+// protected by a password which haven't been unlocked, and snippets of other
+// users which burn after reading, can't be forked, so errNotForkable is
+// returned for them.
+func (app *application) forkableSnippet(r *http.Request, ref string) (*models.Snippet, error) {
+    s, err := app.snippetFromRef(r, ref)
+    if err != nil {
+        return nil, err
+    }
+    if !app.isUnlocked(r, s) || s.BurnAfterReading && !app.isSnippetOwner(r, s) {
+        return nil, errNotForkable
+    }
+
+    return s, nil
+}
+
+// SnippetProvenance returns the snippet the given snippet was forked from, if
+// the user who made the request may follow a link to it, along with the forks
+// of the given snippet they may see. Those are the public ones and their own.
+func (app *application) snippetProvenance(r *http.Request, s *models.Snippet) (*models.Snippet, []*models.Snippet, error) {
+    var original *models.Snippet
+    if s.ForkedFromID != 0 {
+        o, err := app.snippets.Peek(s.ForkedFromID)
+        if err != nil && !errors.Is(err, models.ErrNoRecord) && !errors.Is(err, models.ErrBurned) {
+            return nil, nil, err
+        }
+        // An unlisted original may be linked from the forks of the users who
+        // knew its link, but a private one only from its owner's forks.
+        if err == nil && (o.Visibility == models.VisibilityPublic || app.isSnippetOwner(r, o) ||
+            o.Visibility == models.VisibilityUnlisted && app.isSnippetOwner(r, s)) {
+            original = o
+        }
+    }
+
+    forks, err := app.snippets.Forks(s.ID)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    visible := []*models.Snippet{}
+    for _, fork := range forks {
+        if fork.Visibility == models.VisibilityPublic || app.isSnippetOwner(r, fork) {
+            visible = append(visible, fork)
+        }
+    }
+
+    return original, visible, nil
+}
+
+// SnippetFilename returns the name of the file the given snippet is downloaded
+// as, which is made of the words of its title and the extension of its
+// language, eg. "restart-the-api.sh". Snippets made of several files are
+// downloaded as a zip archive, eg. "docker-setup.zip".
+func snippetFilename(s *models.Snippet) string {
+    words := strings.FieldsFunc(strings.ToLower(s.Title), func(r rune) bool {
+        return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+    })
+    name := strings.Join(words, "-")
+    if name == "" {
+        name = fmt.Sprintf("snippet-%d", s.ID)
+    }
+
+    if len(s.Files) > 0 {
+        return name + ".zip"
+    }
+
+    return name + highlight.Extension(s.Language)
+}
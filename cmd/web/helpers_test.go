@@ -0,0 +1,46 @@
+package main
+
+import (
+    "testing"
+
+    "jackson.software/snippetbox/pkg/models"
+)
+
+func TestSnippetFilename(t *testing.T) {
+    tests := []struct {
+        name    string
+        snippet *models.Snippet
+        want    string
+    }{
+        {
+            name:    "Language",
+            snippet: &models.Snippet{ID: 1, Title: "Restart the API!", Language: "bash"},
+            want:    "restart-the-api.sh",
+        },
+        {
+            name:    "Plain text",
+            snippet: &models.Snippet{ID: 1, Title: "nginx.conf"},
+            want:    "nginx-conf.txt",
+        },
+        {
+            name:    "Several files",
+            snippet: &models.Snippet{ID: 1, Title: "Docker setup", Language: "docker", Files: []*models.File{{Filename: "run.sh"}}},
+            want:    "docker-setup.zip",
+        },
+        {
+            name:    "No words",
+            snippet: &models.Snippet{ID: 7, Title: "???", Language: "go"},
+            want:    "snippet-7.go",
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got := snippetFilename(tt.snippet)
+
+            if got != tt.want {
+                t.Errorf("want %q; got %q", tt.want, got)
+            }
+        })
+    }
+}
@@ -1,51 +1,84 @@
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"database/sql"
+	"errors"
 	"flag"
 	"html/template"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
-	"jackson.software/snippetbox/pkg/models/mysql"
+	"jackson.software/snippetbox/pkg/migrations"
+	"jackson.software/snippetbox/pkg/models"
 
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/golangcollege/sessions"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
 )
 
 type contextKey string
 
-const contextKeyIsAuthenticated = contextKey("isAuthenticated")
+const (
+	contextKeyAuthenticatedUserID = contextKey("authenticatedUserID")
+	contextKeyIsAuthenticated     = contextKey("isAuthenticated")
+	contextKeySnippet             = contextKey("snippet")
+	contextKeyToken               = contextKey("token")
+)
 
 // Application struct holds application specific dependencies, so that
 // they are accessable across the whole application.
 type application struct {
+	collections   models.CollectionStore
+	comments      models.CommentStore
 	errorLog      *log.Logger
 	infoLog       *log.Logger
+	revisions     models.RevisionStore
 	session       *sessions.Session
-	snippets      *mysql.SnippetRepository
+	snippets      models.SnippetStore
+	stars         models.StarStore
 	templateCache map[string]*template.Template
-	users         *mysql.UserRepository
+	tokens        models.TokenStore
+	users         models.UserStore
 }
 
 func main() {
 	addr := flag.String("addr", ":4000", "HTTP network address")
-	dsn := flag.String("dsn", "web:pass@/snippetbox?parseTime=true", "MySQL data source name")
+	driver := flag.String("db-driver", "mysql", "Storage backend (mysql, postgres, sqlite or memory)")
+	dsn := flag.String("dsn", "", "Data source name (defaults to a local database of the chosen driver)")
 	secret := flag.String("secret", "73Ou4jxTwVgCJWjACOrXh12CZomCFIE2", "Secret key")
+	autoMigrate := flag.Bool("auto-migrate", false, "Apply pending database migrations on startup")
+	reapInterval := flag.Duration("reap-interval", time.Hour, "How often expired snippets are deleted (0 disables it)")
+	reapBatchSize := flag.Int("reap-batch-size", 1000, "Maximum number of expired snippets deleted at once")
 	flag.Parse()
 
 	infoLog := log.New(os.Stdout, "INFO\t", log.Ldate|log.Ltime)
 	errorLog := log.New(os.Stderr, "ERROR\t", log.Ldate|log.Ltime|log.Llongfile)
 
-	db, err := openDB(*dsn)
-	if err != nil {
-		errorLog.Fatal(err)
+	// Usage: web [flags] migrate up|down|status [flags]
+	if flag.Arg(0) == "migrate" {
+		if flag.NArg() < 2 {
+			errorLog.Fatal("missing migrate action (want up, down or status)")
+		}
+		action := flag.Arg(1)
+		// Allow flags after the action as well, eg. "web migrate up -db-driver=sqlite".
+		flag.CommandLine.Parse(flag.Args()[2:])
+
+		if err := migrate(*driver, *dsn, action, infoLog); err != nil {
+			errorLog.Fatal(err)
+		}
+		return
+	}
+
+	if *reapBatchSize < 1 {
+		errorLog.Fatalf("invalid reap batch size %d (want at least 1)", *reapBatchSize)
 	}
-	infoLog.Println("Successfully connected to database")
-	defer db.Close()
 
 	templateCache, err := newTemplateCache("./ui/html")
 	if err != nil {
@@ -60,9 +93,28 @@ func main() {
 		errorLog:      errorLog,
 		infoLog:       infoLog,
 		session:       session,
-		snippets:      &mysql.SnippetRepository{DB: db},
 		templateCache: templateCache,
-		users:         &mysql.UserRepository{DB: db},
+	}
+
+	db, err := app.openStorage(*driver, *dsn)
+	if err != nil {
+		errorLog.Fatal(err)
+	}
+	if db != nil {
+		infoLog.Printf("Successfully connected to %s database", *driver)
+		defer db.Close()
+	} else {
+		infoLog.Println("Using in-memory storage, all data will be lost on shutdown")
+	}
+
+	if *autoMigrate && db != nil {
+		m, err := migrations.New(db, *driver)
+		if err != nil {
+			errorLog.Fatal(err)
+		}
+		if err = migrateUp(m, infoLog); err != nil {
+			errorLog.Fatal(err)
+		}
 	}
 
 	tlsConfig := &tls.Config{
@@ -80,13 +132,46 @@ func main() {
 		WriteTimeout: 10 * time.Second,
 	}
 
+	ctx, stop := context.WithCancel(context.Background())
+	reaperDone := make(chan struct{})
+	go func() {
+		defer close(reaperDone)
+		if *reapInterval > 0 {
+			app.reapExpiredSnippets(ctx, *reapInterval, *reapBatchSize)
+		}
+	}()
+
+	// Shut down gracefully on SIGINT and SIGTERM, letting in-flight
+	// requests finish before the database is closed.
+	shutdownErr := make(chan error)
+	go func() {
+		quit := make(chan os.Signal, 1)
+		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+		s := <-quit
+		infoLog.Printf("Received %s, shutting down", s)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+		shutdownErr <- srv.Shutdown(ctx)
+	}()
+
 	infoLog.Printf("Starting server on %s", *addr)
 	err = srv.ListenAndServeTLS("./tls/cert.pem", "./tls/key.pem")
-	errorLog.Fatal(err)
+	if !errors.Is(err, http.ErrServerClosed) {
+		errorLog.Fatal(err)
+	}
+
+	if err = <-shutdownErr; err != nil {
+		errorLog.Println(err)
+	}
+
+	stop()
+	<-reaperDone
+	infoLog.Println("Server stopped")
 }
 
-func openDB(dsn string) (*sql.DB, error) {
-	db, err := sql.Open("mysql", dsn)
+func openDB(driver, dsn string) (*sql.DB, error) {
+	db, err := sql.Open(driver, dsn)
 	if err != nil {
 		return nil, err
 	}
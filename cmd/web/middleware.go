@@ -6,6 +6,7 @@ import (
     "fmt"
     "jackson.software/snippetbox/pkg/models"
     "net/http"
+    "strings"
 
 	"github.com/justinas/nosurf"
 )
@@ -99,6 +100,81 @@ func (app *application) authenticate(next http.Handler) http.Handler {
         }
 
         ctx := context.WithValue(r.Context(), contextKeyIsAuthenticated, true)
+        ctx = context.WithValue(ctx, contextKeyAuthenticatedUserID, user.ID)
+        next.ServeHTTP(w, r.WithContext(ctx))
+    })
+}
+
+// AuthenticateToken checks the bearer token sent in the Authorization header
+// of a request. If the token is valid and belongs to an active user, the same
+// context values as authenticate are added to the request, along with the
+// token itself, so its scopes can be checked. Requests with an invalid token
+// are passed on as unauthenticated, even if they come with a session, and
+// requests without a token are passed on untouched.
+func (app *application) authenticateToken(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        header := r.Header.Get("Authorization")
+        if header == "" {
+            next.ServeHTTP(w, r)
+            return
+        }
+
+        unauthenticated := context.WithValue(r.Context(), contextKeyIsAuthenticated, false)
+
+        token := strings.TrimPrefix(header, "Bearer ")
+        if token == header {
+            next.ServeHTTP(w, r.WithContext(unauthenticated))
+            return
+        }
+
+        t, err := app.tokens.Authenticate(token)
+        if errors.Is(err, models.ErrInvalidCredentials) {
+            next.ServeHTTP(w, r.WithContext(unauthenticated))
+            return
+        } else if err != nil {
+            app.serverError(w, err)
+            return
+        }
+
+        // user is not valid (might have been removed etc.)
+        user, err := app.users.Get(t.UserID)
+        if errors.Is(err, models.ErrNoRecord) || err == nil && !user.Active {
+            next.ServeHTTP(w, r.WithContext(unauthenticated))
+            return
+        } else if err != nil {
+            app.serverError(w, err)
+            return
+        }
+
+        ctx := context.WithValue(r.Context(), contextKeyIsAuthenticated, true)
+        ctx = context.WithValue(ctx, contextKeyAuthenticatedUserID, user.ID)
+        ctx = context.WithValue(ctx, contextKeyToken, t)
+        next.ServeHTTP(w, r.WithContext(ctx))
+    })
+}
+
+// RequireSnippetOwner loads the snippet found by the id route parameter and
+// only lets the request through if it comes from the user who created the
+// snippet. Everybody else gets a 403 forbidden response. The loaded snippet
+// is added to the request context, so handlers don't have to load it again.
+func (app *application) requireSnippetOwner(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        s, err := app.snippetFromPath(r)
+        if err != nil {
+            if errors.Is(err, models.ErrNoRecord) || errors.Is(err, models.ErrBurned) {
+                app.notFound(w)
+            } else {
+                app.serverError(w, err)
+            }
+            return
+        }
+
+        if !app.isSnippetOwner(r, s) {
+            app.clientError(w, http.StatusForbidden)
+            return
+        }
+
+        ctx := context.WithValue(r.Context(), contextKeySnippet, s)
         next.ServeHTTP(w, r.WithContext(ctx))
     })
 }
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"jackson.software/snippetbox/pkg/models"
+)
+
+func TestRequireSnippetOwner(t *testing.T) {
+	app := newTestApplication(t)
+	insertTestUsers(t, app, "alice", "bob")
+	insertTestSnippet(t, app, &models.Snippet{UserID: 1})
+
+	ts := newTestServer(t, app.routes())
+	anonymous := ts.newClient(t)
+	anonymous.get(t, "/users/login")
+	alice := ts.newClient(t)
+	alice.login(t, "alice@example.com")
+	bob := ts.newClient(t)
+	bob.login(t, "bob@example.com")
+
+	tests := []struct {
+		name         string
+		client       *testClient
+		wantCode     int
+		wantLocation string
+	}{
+		{"Anonymous", anonymous, http.StatusSeeOther, "/users/login"},
+		{"Other user", bob, http.StatusForbidden, ""},
+		{"Owner", alice, http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, header, _ := tt.client.get(t, "/snippets/1/edit")
+			if code != tt.wantCode || header.Get("Location") != tt.wantLocation {
+				t.Errorf("want status %d and location %q for the edit form; got %d and %q", tt.wantCode, tt.wantLocation, code, header.Get("Location"))
+			}
+
+			if tt.client == alice {
+				return
+			}
+			code, header, _ = tt.client.postForm(t, "/snippets/1/delete", url.Values{})
+			if code != tt.wantCode || header.Get("Location") != tt.wantLocation {
+				t.Errorf("want status %d and location %q for deleting; got %d and %q", tt.wantCode, tt.wantLocation, code, header.Get("Location"))
+			}
+			code, header, _ = tt.client.postForm(t, "/snippets/1/edit", url.Values{"title": {"Mine now"}, "content": {"rm -rf /"}, "expires": {"never"}})
+			if code != tt.wantCode || header.Get("Location") != tt.wantLocation {
+				t.Errorf("want status %d and location %q for editing; got %d and %q", tt.wantCode, tt.wantLocation, code, header.Get("Location"))
+			}
+		})
+	}
+
+	s, err := app.snippets.Peek(1)
+	if err != nil {
+		t.Fatalf("want the snippet to be left alone by others; got %v", err)
+	}
+	if s.Title != "Snippet" {
+		t.Errorf("want the snippet to be left unedited by others; got the title %q", s.Title)
+	}
+
+	code, header, _ := alice.postForm(t, "/snippets/1/delete", url.Values{})
+	if code != http.StatusSeeOther || header.Get("Location") != "/" {
+		t.Errorf("want the owner to be redirected home after deleting; got %d and %q", code, header.Get("Location"))
+	}
+	if _, err = app.snippets.Peek(1); !errors.Is(err, models.ErrNoRecord) {
+		t.Errorf("want the snippet to be deleted by its owner; got %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"jackson.software/snippetbox/pkg/migrations"
+)
+
+// Migrate runs the migrate subcommand with the given action, which is one of
+// up, down or status, against the database of the given driver.
+func migrate(driver, dsn, action string, infoLog *log.Logger) error {
+	db, err := openDatabase(driver, dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	m, err := migrations.New(db, driver)
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "up":
+		return migrateUp(m, infoLog)
+	case "down":
+		mig, err := m.Down()
+		if errors.Is(err, migrations.ErrNoMigration) {
+			infoLog.Println("No migration to revert")
+			return nil
+		} else if err != nil {
+			return err
+		}
+		infoLog.Printf("Reverted migration %06d_%s", mig.Version, mig.Name)
+		return nil
+	case "status":
+		statuses, err := m.Status()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+		for _, s := range statuses {
+			applied := "pending"
+			if !s.Pending() {
+				applied = humanDate(s.Applied)
+			}
+			fmt.Fprintf(w, "%06d\t%s\t%s\n", s.Migration.Version, s.Migration.Name, applied)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown migrate action %q (want up, down or status)", action)
+	}
+}
+
+// MigrateUp applies all pending migrations and logs each applied one.
+func migrateUp(m *migrations.Migrator, infoLog *log.Logger) error {
+	applied, err := m.Up()
+	for _, mig := range applied {
+		infoLog.Printf("Applied migration %06d_%s", mig.Version, mig.Name)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(applied) == 0 {
+		infoLog.Println("Database schema is up to date")
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"jackson.software/snippetbox/pkg/models"
+)
+
+// SnippetsPerPage is the number of snippets shown on a page of a paginated
+// snippet listing.
+const snippetsPerPage = 20
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// Pagination holds the cursors of the neighbouring pages of a snippet listing.
+// An empty cursor means there is no page in that direction.
+type pagination struct {
+	Newer string
+	Older string
+}
+
+// EncodeCursor returns an opaque cursor marking the position of the given
+// snippet, which is safe to use in URLs.
+func encodeCursor(s *models.Snippet) string {
+	raw := strconv.FormatInt(s.Created.UnixNano(), 10) + "-" + strconv.Itoa(s.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseCursor reads the cursor from the newer or older query parameter. If
+// neither is given, the zero cursor marking the start of the list is returned.
+func parseCursor(query url.Values) (models.Cursor, error) {
+	cursor := models.Cursor{}
+
+	token := query.Get("older")
+	if newer := query.Get("newer"); newer != "" {
+		token = newer
+		cursor.Newer = true
+	}
+	if token == "" {
+		return cursor, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return cursor, errInvalidCursor
+	}
+
+	parts := strings.SplitN(string(raw), "-", 2)
+	if len(parts) != 2 {
+		return cursor, errInvalidCursor
+	}
+
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return cursor, errInvalidCursor
+	}
+
+	cursor.ID, err = strconv.Atoi(parts[1])
+	if err != nil || cursor.ID < 1 {
+		return cursor, errInvalidCursor
+	}
+
+	cursor.Created = time.Unix(0, nanos).UTC()
+	return cursor, nil
+}
+
+// Paginate trims a page of snippets, which has been loaded with one snippet
+// more than shown to tell whether there are more, and returns the page along
+// with the cursors of its neighbouring pages.
+func paginate(cursor models.Cursor, snippets []*models.Snippet, perPage int) ([]*models.Snippet, *pagination) {
+	p := &pagination{}
+	more := len(snippets) > perPage
+
+	if cursor.Newer {
+		if more {
+			snippets = snippets[len(snippets)-perPage:]
+		}
+	} else if more {
+		snippets = snippets[:perPage]
+	}
+
+	if len(snippets) == 0 {
+		return snippets, p
+	}
+
+	// Coming from a page in one direction means there are more snippets in
+	// that direction.
+	if more && cursor.Newer || !cursor.IsZero() && !cursor.Newer {
+		p.Newer = encodeCursor(snippets[0])
+	}
+	if more && !cursor.Newer || cursor.Newer {
+		p.Older = encodeCursor(snippets[len(snippets)-1])
+	}
+
+	return snippets, p
+}
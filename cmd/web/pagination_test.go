@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/base64"
+	"net/url"
+	"testing"
+	"time"
+
+	"jackson.software/snippetbox/pkg/models"
+)
+
+func TestParseCursor(t *testing.T) {
+	s := &models.Snippet{ID: 42, Created: time.Date(2020, 12, 17, 10, 0, 0, 123456000, time.UTC)}
+	token := encodeCursor(s)
+
+	tests := []struct {
+		name    string
+		query   url.Values
+		want    models.Cursor
+		wantErr bool
+	}{
+		{
+			name:  "Start",
+			query: url.Values{},
+			want:  models.Cursor{},
+		},
+		{
+			name:  "Older",
+			query: url.Values{"older": {token}},
+			want:  models.Cursor{Created: s.Created, ID: 42},
+		},
+		{
+			name:  "Newer",
+			query: url.Values{"newer": {token}},
+			want:  models.Cursor{Created: s.Created, ID: 42, Newer: true},
+		},
+		{
+			name:    "Garbage",
+			query:   url.Values{"older": {"not a cursor"}},
+			wantErr: true,
+		},
+		{
+			name:    "Invalid ID",
+			query:   url.Values{"older": {base64.RawURLEncoding.EncodeToString([]byte("1608199200000000000-0"))}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCursor(tt.query)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("want error; got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Created.Equal(tt.want.Created) || got.ID != tt.want.ID || got.Newer != tt.want.Newer {
+				t.Errorf("want %+v; got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	snippets := func(ids ...int) []*models.Snippet {
+		s := []*models.Snippet{}
+		for _, id := range ids {
+			s = append(s, &models.Snippet{ID: id, Created: time.Unix(int64(id), 0)})
+		}
+		return s
+	}
+	cursor := func(id int, newer bool) models.Cursor {
+		return models.Cursor{ID: id, Created: time.Unix(int64(id), 0), Newer: newer}
+	}
+
+	tests := []struct {
+		name      string
+		cursor    models.Cursor
+		snippets  []*models.Snippet
+		wantIDs   []int
+		wantNewer int
+		wantOlder int
+	}{
+		{"Single page", models.Cursor{}, snippets(2, 1), []int{2, 1}, 0, 0},
+		{"First page", models.Cursor{}, snippets(9, 8, 7), []int{9, 8}, 0, 8},
+		{"Older page", cursor(8, false), snippets(7, 6, 5), []int{7, 6}, 7, 6},
+		{"Last page", cursor(2, false), snippets(1), []int{1}, 1, 0},
+		{"Newer page", cursor(5, true), snippets(8, 7, 6), []int{7, 6}, 7, 6},
+		{"Newest page", cursor(7, true), snippets(9, 8), []int{9, 8}, 0, 8},
+		{"Empty", cursor(1, false), snippets(), []int{}, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, p := paginate(tt.cursor, tt.snippets, 2)
+
+			ids := []int{}
+			for _, s := range got {
+				ids = append(ids, s.ID)
+			}
+			if len(ids) != len(tt.wantIDs) {
+				t.Fatalf("want snippets %v; got %v", tt.wantIDs, ids)
+			}
+			for i := range ids {
+				if ids[i] != tt.wantIDs[i] {
+					t.Fatalf("want snippets %v; got %v", tt.wantIDs, ids)
+				}
+			}
+
+			for _, c := range []struct {
+				name  string
+				token string
+				want  int
+			}{{"newer", p.Newer, tt.wantNewer}, {"older", p.Older, tt.wantOlder}} {
+				if c.want == 0 {
+					if c.token != "" {
+						t.Errorf("want no %s cursor; got %q", c.name, c.token)
+					}
+					continue
+				}
+				if want := encodeCursor(snippets(c.want)[0]); c.token != want {
+					t.Errorf("want %s cursor of snippet %d; got %q", c.name, c.want, c.token)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"jackson.software/snippetbox/pkg/forms"
+	"jackson.software/snippetbox/pkg/models"
+)
+
+// MaxPasteSize is the maximum size of the body of a paste request.
+const maxPasteSize = 1 << 20
+
+// Default title and expiry time of pasted snippets, which are usually piped in
+// by a command line without giving any.
+const (
+	defaultPasteTitle   = "Untitled"
+	defaultPasteExpires = "8760h"
+)
+
+// PasteSnippet handler creates a snippet from the body of the request, or
+// from the file field of a multipart form, and sends its URL as plain text.
+// The title, expiry time and language are taken from the query parameters
+// or, if missing there, from the X-Title, X-Expires and X-Language headers.
+//
+// Since it's meant for curl, which sends --data-binary bodies as form data,
+// a body is only parsed as a form if it's a multipart one.
+func (app *application) pasteSnippet(w http.ResponseWriter, r *http.Request) {
+	if r.ContentLength > maxPasteSize {
+		app.clientError(w, http.StatusRequestEntityTooLarge)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxPasteSize)
+
+	content, filename, err := readPaste(r)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	title := pasteParam(r, "title", "X-Title")
+	if title == "" {
+		title = filename
+	}
+	if title == "" {
+		title = defaultPasteTitle
+	}
+	expires := pasteParam(r, "expires", "X-Expires")
+	if expires == "" {
+		expires = defaultPasteExpires
+	}
+
+	form := forms.New(url.Values{
+		"title":    {title},
+		"content":  {content},
+		"language": {pasteParam(r, "language", "X-Language")},
+		"expires":  {expires},
+	})
+	validateNewSnippet(form)
+	if !utf8.ValidString(content) {
+		form.Errors.Add("content", "This field must be text")
+	}
+
+	if !form.Valid() {
+		pasteValidationError(w, form)
+		return
+	}
+
+	s, err := app.insertSnippet(r, form, 0)
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	scheme := "https"
+	if r.TLS == nil {
+		scheme = "http"
+	}
+	u := fmt.Sprintf("%s://%s%s", scheme, r.Host, s.Path())
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Location", u)
+	w.WriteHeader(http.StatusCreated)
+	fmt.Fprintln(w, u)
+}
+
+// ReadPaste returns the content of a paste request along with the name of the
+// uploaded file, which is empty unless the content comes from a multipart form.
+func readPaste(r *http.Request) (string, string, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		b, err := ioutil.ReadAll(r.Body)
+		return string(b), "", err
+	}
+
+	if err := r.ParseMultipartForm(maxPasteSize); err != nil {
+		return "", "", err
+	}
+	f, fh, err := r.FormFile("file")
+	if err != nil {
+		return "", "", err
+	}
+	defer f.Close()
+
+	b, err := ioutil.ReadAll(f)
+	return string(b), fh.Filename, err
+}
+
+// PasteParam returns the value of the query parameter with the given name, or
+// the value of the given header if there's no such parameter.
+func pasteParam(r *http.Request, name, header string) string {
+	if v := r.URL.Query().Get(name); v != "" {
+		return v
+	}
+
+	return r.Header.Get(header)
+}
+
+// PasteValidationError sends the errors of the given invalid form as plain
+// text, one line per field, with a 422 unprocessable entity status code.
+func pasteValidationError(w http.ResponseWriter, form *forms.Form) {
+	fields := make([]string, 0, len(form.Errors))
+	for field := range form.Errors {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	for _, field := range fields {
+		fmt.Fprintf(w, "%s: %s\n", field, strings.Join(form.Errors[field], ", "))
+	}
+}
+
+// RequirePasteToken only lets requests through which are authenticated by an
+// API token with the snippets:write scope. Unlike on the JSON API, a session
+// isn't enough, and errors are sent as plain text.
+func (app *application) requirePasteToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t := app.tokenFromContext(r)
+		if t == nil {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			app.clientError(w, http.StatusUnauthorized)
+			return
+		}
+		if !t.HasScope(models.ScopeSnippetsWrite) {
+			app.clientError(w, http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
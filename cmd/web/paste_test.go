@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReadPaste(t *testing.T) {
+	var multipartBody bytes.Buffer
+	mw := multipart.NewWriter(&multipartBody)
+	fw, err := mw.CreateFormFile("file", "build.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write([]byte("ok\n"))
+	mw.Close()
+
+	tests := []struct {
+		name         string
+		contentType  string
+		body         string
+		wantContent  string
+		wantFilename string
+	}{
+		{
+			name:        "Raw",
+			body:        "echo hi\n",
+			wantContent: "echo hi\n",
+		},
+		{
+			name:        "Form data",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "a=b&c",
+			wantContent: "a=b&c",
+		},
+		{
+			name:         "Multipart",
+			contentType:  mw.FormDataContentType(),
+			body:         multipartBody.String(),
+			wantContent:  "ok\n",
+			wantFilename: "build.log",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/paste", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+
+			content, filename, err := readPaste(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if content != tt.wantContent {
+				t.Errorf("want content %q; got %q", tt.wantContent, content)
+			}
+			if filename != tt.wantFilename {
+				t.Errorf("want filename %q; got %q", tt.wantFilename, filename)
+			}
+		})
+	}
+}
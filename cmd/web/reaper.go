@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// ReapExpiredSnippets deletes expired snippets right away and then every
+// interval, until the given context is cancelled. Snippets are deleted in
+// batches of the given size, so that a large backlog doesn't lock the
+// snippets table for a long time.
+func (app *application) reapExpiredSnippets(ctx context.Context, interval time.Duration, batchSize int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		app.reap(ctx, batchSize)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Reap deletes batches of expired snippets until there are none left and logs
+// how many have been deleted.
+func (app *application) reap(ctx context.Context, batchSize int) {
+	total := 0
+	for ctx.Err() == nil {
+		n, err := app.snippets.DeleteExpired(batchSize)
+		if err != nil {
+			app.errorLog.Printf("reaping expired snippets: %s", err)
+			break
+		}
+
+		total += n
+		if n < batchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		app.infoLog.Printf("Deleted %d expired snippets", total)
+	}
+}
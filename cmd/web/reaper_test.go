@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"testing"
+
+	"jackson.software/snippetbox/pkg/models"
+)
+
+// ExpiredSnippetStore is a snippet store holding the given number of expired
+// snippets, which records how often DeleteExpired has been called.
+type expiredSnippetStore struct {
+	models.SnippetStore
+	expired int
+	err     error
+	calls   int
+}
+
+func (s *expiredSnippetStore) DeleteExpired(limit int) (int, error) {
+	s.calls++
+	if s.err != nil {
+		return 0, s.err
+	}
+
+	n := limit
+	if s.expired < n {
+		n = s.expired
+	}
+	s.expired -= n
+	return n, nil
+}
+
+func TestReap(t *testing.T) {
+	tests := []struct {
+		name      string
+		expired   int
+		err       error
+		cancelled bool
+		wantCalls int
+	}{
+		{"None expired", 0, nil, false, 1},
+		{"Partial batch", 5, nil, false, 3},
+		{"Full batches", 4, nil, false, 3},
+		{"Error", 5, errors.New("database is gone"), false, 1},
+		{"Cancelled", 5, nil, true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &expiredSnippetStore{expired: tt.expired, err: tt.err}
+			app := &application{
+				errorLog: log.New(io.Discard, "", 0),
+				infoLog:  log.New(io.Discard, "", 0),
+				snippets: store,
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			if tt.cancelled {
+				cancel()
+			}
+			defer cancel()
+
+			app.reap(ctx, 2)
+			if store.calls != tt.wantCalls {
+				t.Errorf("want %d batches; got %d", tt.wantCalls, store.calls)
+			}
+			if tt.err == nil && !tt.cancelled && store.expired != 0 {
+				t.Errorf("want all expired snippets to be deleted; got %d left", store.expired)
+			}
+		})
+	}
+}
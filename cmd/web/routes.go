@@ -5,24 +5,67 @@ import (
 
 	"github.com/go-zoo/bone"
 	"github.com/justinas/alice"
+	"jackson.software/snippetbox/pkg/models"
 )
 
 func (app *application) routes() http.Handler {
 	standardMiddleware := alice.New(app.recoverPanic, app.logRequest, secureHeaders)
 	dynamicMiddleware := alice.New(app.session.Enable, noSurf, app.authenticate)
+	apiMiddleware := alice.New(app.session.Enable, app.authenticate, app.authenticateToken)
+	apiReadMiddleware := apiMiddleware.Append(app.apiRequireScope(models.ScopeSnippetsRead))
+	apiWriteMiddleware := apiMiddleware.Append(app.apiRequireAuthentication, app.apiRequireScope(models.ScopeSnippetsWrite))
+	pasteMiddleware := alice.New(app.authenticateToken, app.requirePasteToken)
 
 	mux := bone.New()
 
 	mux.Get("/", dynamicMiddleware.ThenFunc(app.home)).Options()
+	mux.Get("/snippets", dynamicMiddleware.ThenFunc(app.listSnippets))
 	mux.Get("/snippets/create", dynamicMiddleware.Append(app.requireAuthentication).ThenFunc(app.showSnippetForm))
-	mux.Get("/snippets/:id", dynamicMiddleware.Append(app.requireAuthentication).ThenFunc(app.showSnippet))
+	mux.Get("/snippets/:id", dynamicMiddleware.ThenFunc(app.showSnippet))
+	mux.Get("/snippets/:id/raw", dynamicMiddleware.ThenFunc(app.showRawSnippet))
+	mux.Get("/snippets/:id/raw/:filename", dynamicMiddleware.ThenFunc(app.showRawSnippetFile))
+	mux.Get("/snippets/:id/download", dynamicMiddleware.ThenFunc(app.downloadSnippet))
+	mux.Get("/snippets/:id/fork", dynamicMiddleware.Append(app.requireAuthentication).ThenFunc(app.forkSnippetForm))
+	mux.Post("/snippets/:id/comments", dynamicMiddleware.Append(app.requireAuthentication).ThenFunc(app.createComment))
+	mux.Post("/snippets/:id/star", dynamicMiddleware.Append(app.requireAuthentication).ThenFunc(app.toggleStar))
+	mux.Post("/snippets/:id/unlock", dynamicMiddleware.ThenFunc(app.unlockSnippet))
 	mux.Post("/snippets", dynamicMiddleware.Append(app.requireAuthentication).ThenFunc(app.createSnippet))
+	mux.Get("/snippets/:id/edit", dynamicMiddleware.Append(app.requireAuthentication, app.requireSnippetOwner).ThenFunc(app.editSnippetForm))
+	mux.Post("/snippets/:id/edit", dynamicMiddleware.Append(app.requireAuthentication, app.requireSnippetOwner).ThenFunc(app.editSnippet))
+	mux.Get("/snippets/:id/revisions", dynamicMiddleware.Append(app.requireAuthentication).ThenFunc(app.showRevisions))
+	mux.Get("/snippets/:id/diff", dynamicMiddleware.Append(app.requireAuthentication).ThenFunc(app.showRevisionDiff))
+	mux.Post("/snippets/:id/delete", dynamicMiddleware.Append(app.requireAuthentication, app.requireSnippetOwner).ThenFunc(app.deleteSnippet))
+
+	mux.Get("/collections/create", dynamicMiddleware.Append(app.requireAuthentication).ThenFunc(app.showCollectionForm))
+	mux.Post("/collections", dynamicMiddleware.Append(app.requireAuthentication).ThenFunc(app.createCollection))
+	mux.Get("/collections/:id", dynamicMiddleware.ThenFunc(app.showCollection))
+	mux.Post("/collections/:id/delete", dynamicMiddleware.Append(app.requireAuthentication).ThenFunc(app.deleteCollection))
+	mux.Post("/collections/:id/snippets", dynamicMiddleware.Append(app.requireAuthentication).ThenFunc(app.addCollectionSnippet))
+	mux.Post("/collections/:id/snippets/remove", dynamicMiddleware.Append(app.requireAuthentication).ThenFunc(app.removeCollectionSnippet))
+	mux.Post("/collections/:id/snippets/move", dynamicMiddleware.Append(app.requireAuthentication).ThenFunc(app.moveCollectionSnippet))
+
+	mux.Get("/tags/:tag", dynamicMiddleware.ThenFunc(app.showTagSnippets))
+	mux.Get("/search", dynamicMiddleware.ThenFunc(app.searchSnippets))
 
 	mux.Get("/users/signup", dynamicMiddleware.ThenFunc(app.signupUserForm))
 	mux.Post("/users/signup", dynamicMiddleware.ThenFunc(app.signupUser))
 	mux.Get("/users/login", dynamicMiddleware.ThenFunc(app.loginUserForm))
 	mux.Post("/users/login", dynamicMiddleware.ThenFunc(app.loginUser))
 	mux.Post("/users/logout", dynamicMiddleware.Append(app.requireAuthentication).ThenFunc(app.logoutUser))
+	mux.Get("/me/collections", dynamicMiddleware.Append(app.requireAuthentication).ThenFunc(app.showCollections))
+	mux.Get("/me/starred", dynamicMiddleware.Append(app.requireAuthentication).ThenFunc(app.showStarred))
+	mux.Get("/account/tokens", dynamicMiddleware.Append(app.requireAuthentication).ThenFunc(app.showTokens))
+	mux.Post("/account/tokens", dynamicMiddleware.Append(app.requireAuthentication).ThenFunc(app.createToken))
+	mux.Post("/account/tokens/:id/delete", dynamicMiddleware.Append(app.requireAuthentication).ThenFunc(app.deleteToken))
+	mux.Get("/users/:id/snippets", dynamicMiddleware.ThenFunc(app.showUserSnippets))
+
+	mux.Get("/api/v1/snippets", apiReadMiddleware.ThenFunc(app.apiListSnippets))
+	mux.Post("/api/v1/snippets", apiWriteMiddleware.Append(app.apiRequireJSON).ThenFunc(app.apiCreateSnippet))
+	mux.Get("/api/v1/snippets/:id", apiReadMiddleware.ThenFunc(app.apiShowSnippet))
+	mux.Put("/api/v1/snippets/:id", apiWriteMiddleware.Append(app.apiRequireJSON, app.apiRequireSnippetOwner).ThenFunc(app.apiUpdateSnippet))
+	mux.Delete("/api/v1/snippets/:id", apiWriteMiddleware.Append(app.apiRequireSnippetOwner).ThenFunc(app.apiDeleteSnippet))
+
+	mux.Post("/paste", pasteMiddleware.ThenFunc(app.pasteSnippet))
 
 	fileServer := http.FileServer(http.Dir("./ui/static/"))
 	mux.Handle("/static/", http.StripPrefix("/static", fileServer))
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"html/template"
+	"regexp"
+	"strings"
+
+	"jackson.software/snippetbox/pkg/models"
+)
+
+// MaxSearchResults is the maximum number of snippets shown for a search.
+const maxSearchResults = 50
+
+// ExcerptLines is the number of lines of a snippet's content shown for a
+// search result.
+const excerptLines = 5
+
+// WordRX matches the words of a text the same way search queries are split
+// into terms.
+var wordRX = regexp.MustCompile(`[\p{L}\p{Nd}]+`)
+
+// SearchMatches returns the byte ranges of all words in the given text which
+// are terms of the given search query.
+func searchMatches(text, query string) [][]int {
+	terms := map[string]bool{}
+	for _, t := range models.SearchTerms(query) {
+		terms[t] = true
+	}
+
+	matches := [][]int{}
+	for _, m := range wordRX.FindAllStringIndex(text, -1) {
+		if terms[strings.ToLower(text[m[0]:m[1]])] {
+			matches = append(matches, m)
+		}
+	}
+
+	return matches
+}
+
+// Excerpt returns a few lines of the given content, starting a line before the
+// first one which contains a term of the given search query.
+func excerpt(content, query string) string {
+	start := 0
+	if matches := searchMatches(content, query); len(matches) > 0 {
+		start = strings.Count(content[:matches[0][0]], "\n") - 1
+		if start < 0 {
+			start = 0
+		}
+	}
+
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	end := start + excerptLines
+	if end > len(lines) {
+		end = len(lines)
+	}
+
+	return strings.Join(lines[start:end], "\n")
+}
+
+// MarkMatches escapes the given text and wraps every term of the given search
+// query found in it in a mark element.
+func markMatches(text, query string) template.HTML {
+	var b strings.Builder
+
+	last := 0
+	for _, m := range searchMatches(text, query) {
+		b.WriteString(template.HTMLEscapeString(text[last:m[0]]))
+		b.WriteString("<mark>" + template.HTMLEscapeString(text[m[0]:m[1]]) + "</mark>")
+		last = m[1]
+	}
+	b.WriteString(template.HTMLEscapeString(text[last:]))
+
+	return template.HTML(b.String())
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestExcerpt(t *testing.T) {
+	content := "1\n2\n3\n4 match\n5\n6\n7\n8\n9"
+
+	tests := []struct {
+		name    string
+		content string
+		query   string
+		want    string
+	}{
+		{
+			name:    "Line before the match",
+			content: content,
+			query:   "match",
+			want:    "3\n4 match\n5\n6\n7",
+		},
+		{
+			name:    "No match",
+			content: content,
+			query:   "missing",
+			want:    "1\n2\n3\n4 match\n5",
+		},
+		{
+			name:    "Match on first line",
+			content: "Match\r\nsecond",
+			query:   "match",
+			want:    "Match\nsecond",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := excerpt(tt.content, tt.query)
+
+			if got != tt.want {
+				t.Errorf("want %q; got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestMarkMatches(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		query string
+		want  string
+	}{
+		{
+			name:  "Words only",
+			text:  "Channels of a channel",
+			query: "channel",
+			want:  "Channels of a <mark>channel</mark>",
+		},
+		{
+			name:  "Case insensitive",
+			text:  "Über uber ÜBER",
+			query: "über",
+			want:  "<mark>Über</mark> uber <mark>ÜBER</mark>",
+		},
+		{
+			name:  "Escaped",
+			text:  "<b>go</b> & go",
+			query: "go b",
+			want:  "&lt;<mark>b</mark>&gt;<mark>go</mark>&lt;/<mark>b</mark>&gt; &amp; <mark>go</mark>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(markMatches(tt.text, tt.query))
+
+			if got != tt.want {
+				t.Errorf("want %q; got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"jackson.software/snippetbox/pkg/models"
+)
+
+// StarPeriod is a time window over which the stars of the most starred
+// snippets on the home page are counted.
+type starPeriod struct {
+	ID   string
+	Name string
+	// Duration is zero for the period counting every star ever given.
+	Duration time.Duration
+}
+
+// StarPeriods are the periods the home page can be sorted by.
+var starPeriods = []starPeriod{
+	{"day", "Past day", 24 * time.Hour},
+	{"week", "Past week", 7 * 24 * time.Hour},
+	{"month", "Past month", 30 * 24 * time.Hour},
+	{"year", "Past year", 365 * 24 * time.Hour},
+	{"all", "All time", 0},
+}
+
+// DefaultStarPeriod is the ID of the period the most starred snippets are
+// counted over unless another one is given.
+const defaultStarPeriod = "week"
+
+// StarredSince returns the time since when the stars of the period with the
+// given ID are counted, which is zero for all time. If there's no such period,
+// false is returned.
+func starredSince(period string, now time.Time) (time.Time, bool) {
+	for _, p := range starPeriods {
+		if p.ID != period {
+			continue
+		}
+		if p.Duration == 0 {
+			return time.Time{}, true
+		}
+		return now.Add(-p.Duration), true
+	}
+
+	return time.Time{}, false
+}
+
+// ToggleStar handler stars a snippet for the user, or takes the star back if
+// the user has starred it already. Snippets which haven't been unlocked and
+// snippets which burn after reading can't be starred.
+func (app *application) toggleStar(w http.ResponseWriter, r *http.Request) {
+	s, err := app.snippetFromPath(r)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) || errors.Is(err, models.ErrBurned) {
+			app.notFound(w)
+		} else {
+			app.serverError(w, err)
+		}
+		return
+	}
+	if !app.isUnlocked(r, s) || s.BurnAfterReading {
+		app.clientError(w, http.StatusForbidden)
+		return
+	}
+
+	_, err = app.stars.Toggle(s.ID, app.authenticatedUserID(r))
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	http.Redirect(w, r, s.Path(), http.StatusSeeOther)
+}
+
+// ShowStarred handler shows the snippets starred by the user, most recently
+// starred first.
+func (app *application) showStarred(w http.ResponseWriter, r *http.Request) {
+	s, err := app.snippets.Starred(app.authenticatedUserID(r))
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	app.render(w, r, "starred.page.tmpl", &templateData{Snippets: s})
+}
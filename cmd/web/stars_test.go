@@ -0,0 +1,32 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStarredSince(t *testing.T) {
+	now := time.Date(2021, 3, 8, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		period string
+		want   time.Time
+		ok     bool
+	}{
+		{"day", time.Date(2021, 3, 7, 10, 0, 0, 0, time.UTC), true},
+		{"week", time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC), true},
+		{"all", time.Time{}, true},
+		{"", time.Time{}, false},
+		{"decade", time.Time{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.period, func(t *testing.T) {
+			got, ok := starredSince(tt.period, now)
+
+			if ok != tt.ok || !got.Equal(tt.want) {
+				t.Errorf("want %v, %v; got %v, %v", tt.want, tt.ok, got, ok)
+			}
+		})
+	}
+}
@@ -2,21 +2,44 @@ package main
 
 import (
 	"html/template"
+	"net/url"
 	"path/filepath"
 	"time"
 
 	"jackson.software/snippetbox/pkg/forms"
+	"jackson.software/snippetbox/pkg/highlight"
 	"jackson.software/snippetbox/pkg/models"
 )
 
 type templateData struct {
-	CSRFToken       string
-	CurrentYear     int
-	Flash           string
-	Form            *forms.Form
-	IsAuthenticated bool
-	Snippet         *models.Snippet
-	Snippets        []*models.Snippet
+	AuthenticatedUserID int
+	CSRFToken           string
+	Collection          *models.Collection
+	Collections         []*models.Collection
+	Comments            []*models.Comment
+	CurrentYear         int
+	Diff                []fileDiff
+	Flash               string
+	ForkedFrom          *models.Snippet
+	Forks               []*models.Snippet
+	Form                *forms.Form
+	FromRevision        *models.Revision
+	IsAuthenticated     bool
+	Lines               []codeLine
+	Members             []collectionMember
+	NewToken            string
+	Pagination          *pagination
+	Period              string
+	Query               string
+	Revisions           []*models.Revision
+	Snippet             *models.Snippet
+	Snippets            []*models.Snippet
+	Sort                string
+	Starred             bool
+	Tag                 string
+	ToRevision          *models.Revision
+	Tokens              []*models.Token
+	User                *models.User
 }
 
 // Transforms the given date time to a better human readable presentation.
@@ -28,8 +51,33 @@ func humanDate(t time.Time) string {
 	return t.UTC().Format("02 Jan 2006 at 15:04")
 }
 
+// HighlightCode renders the given content as HTML with syntax highlighting for
+// the language with the given ID.
+func highlightCode(content, language string) (template.HTML, error) {
+	h, err := highlight.HTML(content, language)
+	return template.HTML(h), err
+}
+
+// StarPeriods returns the periods the most starred snippets can be counted over.
+func starPeriodsFunc() []starPeriod {
+	return starPeriods
+}
+
+// Languages returns the languages a snippet can be written in.
+func languages() []highlight.Language {
+	return highlight.Languages
+}
+
 var functions = template.FuncMap{
-	"humanDate": humanDate,
+	"excerpt":      excerpt,
+	"fileNumbers":  fileNumbers,
+	"highlight":    highlightCode,
+	"humanDate":    humanDate,
+	"languageName": highlight.Name,
+	"languages":    languages,
+	"markMatches":  markMatches,
+	"pathEscape":   url.PathEscape,
+	"starPeriods":  starPeriodsFunc,
 }
 
 // NewTemplateCache creates a cache of templates indexed by their page name,
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"html"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golangcollege/sessions"
+	"jackson.software/snippetbox/pkg/models"
+)
+
+// NewTestApplication returns an application which keeps its data in memory,
+// renders the templates of the site and discards its logs.
+func newTestApplication(t *testing.T) *application {
+	templateCache, err := newTemplateCache("../../ui/html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session := sessions.New([]byte("3dSm5MnygFHh7XidAtbskXrjbwfoJcbJ"))
+	session.Lifetime = 12 * time.Hour
+	session.Secure = true
+
+	app := &application{
+		errorLog:      log.New(ioutil.Discard, "", 0),
+		infoLog:       log.New(ioutil.Discard, "", 0),
+		session:       session,
+		templateCache: templateCache,
+	}
+	if _, err := app.openStorage("memory", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	return app
+}
+
+// TestServer serves the routes of an application over TLS.
+type testServer struct {
+	*httptest.Server
+}
+
+func newTestServer(t *testing.T, h http.Handler) *testServer {
+	ts := httptest.NewTLSServer(h)
+	t.Cleanup(ts.Close)
+
+	return &testServer{ts}
+}
+
+// TestClient is a browser visiting a test server, with its own cookies, which
+// doesn't follow redirects. It remembers the CSRF token of the latest page it
+// got, so it can post forms.
+type testClient struct {
+	ts        *testServer
+	client    *http.Client
+	csrfToken string
+}
+
+var csrfTokenRX = regexp.MustCompile(`name='csrf_token' value='(.+?)'`)
+
+func (ts *testServer) newClient(t *testing.T) *testClient {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := *ts.Client()
+	client.Jar = jar
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	return &testClient{ts: ts, client: &client}
+}
+
+func (c *testClient) do(t *testing.T, req *http.Request) (int, http.Header, string) {
+	t.Helper()
+	rs, err := c.client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rs.Body.Close()
+
+	body, err := ioutil.ReadAll(rs.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := csrfTokenRX.FindSubmatch(body); m != nil {
+		c.csrfToken = html.UnescapeString(string(m[1]))
+	}
+
+	return rs.StatusCode, rs.Header, string(body)
+}
+
+func (c *testClient) get(t *testing.T, urlPath string) (int, http.Header, string) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, c.ts.URL+urlPath, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c.do(t, req)
+}
+
+func (c *testClient) postForm(t *testing.T, urlPath string, form url.Values) (int, http.Header, string) {
+	t.Helper()
+	form.Set("csrf_token", c.csrfToken)
+	req, err := http.NewRequest(http.MethodPost, c.ts.URL+urlPath, strings.NewReader(form.Encode()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	// The CSRF protection only accepts requests over HTTPS coming from the site.
+	req.Header.Set("Referer", c.ts.URL+"/")
+
+	return c.do(t, req)
+}
+
+// Login logs the client in as the user with the given email address, whose
+// password is "pa55word123".
+func (c *testClient) login(t *testing.T, email string) {
+	t.Helper()
+	c.get(t, "/users/login")
+	code, _, _ := c.postForm(t, "/users/login", url.Values{"email": {email}, "password": {"pa55word123"}})
+	if code != http.StatusSeeOther {
+		t.Fatalf("want status %d after logging in as %s; got %d", http.StatusSeeOther, email, code)
+	}
+}
+
+// InsertTestUsers inserts a user for each of the given names, whose IDs count
+// up from 1. Their email addresses are their name at example.com.
+func insertTestUsers(t *testing.T, app *application, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		if err := app.users.Insert(name, name+"@example.com", "pa55word123"); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// InsertTestSnippet inserts the given snippet, giving it a title and content
+// unless it has them, and returns its ID.
+func insertTestSnippet(t *testing.T, app *application, s *models.Snippet) int {
+	t.Helper()
+	if s.Title == "" {
+		s.Title = "Snippet"
+	}
+	if s.Content == "" {
+		s.Content = "echo hello"
+	}
+
+	id, err := app.snippets.Insert(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return id
+}
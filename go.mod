@@ -1,12 +1,15 @@
 module jackson.software/snippetbox
 
-go 1.14
+go 1.16
 
 require (
+	github.com/alecthomas/chroma v0.10.0
 	github.com/go-sql-driver/mysql v1.5.0
 	github.com/go-zoo/bone v1.3.0
 	github.com/golangcollege/sessions v1.2.0
 	github.com/justinas/alice v1.2.0
 	github.com/justinas/nosurf v1.1.0
+	github.com/lib/pq v1.9.0
+	github.com/mattn/go-sqlite3 v1.14.16
 	golang.org/x/crypto v0.0.0-20200317142112-1b76d66859c6
 )
@@ -0,0 +1,95 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+// Format renders the hunks like diff -u, without the file headers.
+func format(hunks []Hunk) string {
+	var b strings.Builder
+	for _, h := range hunks {
+		b.WriteString(h.Header() + "\n")
+		for _, l := range h.Lines {
+			b.WriteString(l.Prefix() + l.Text + "\n")
+		}
+	}
+	return b.String()
+}
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name    string
+		old     string
+		new     string
+		context int
+		want    string
+	}{
+		{
+			name:    "Identical",
+			old:     "a\nb\nc\n",
+			new:     "a\nb\nc\n",
+			context: 3,
+			want:    "",
+		},
+		{
+			name:    "Changed line",
+			old:     "a\nb\nc\n",
+			new:     "a\nB\nc\n",
+			context: 3,
+			want:    "@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			name:    "From empty",
+			old:     "",
+			new:     "a\nb\n",
+			context: 3,
+			want:    "@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name:    "To empty",
+			old:     "a\nb",
+			new:     "",
+			context: 3,
+			want:    "@@ -1,2 +0,0 @@\n-a\n-b\n",
+		},
+		{
+			name:    "Insertion without context",
+			old:     "a\nb\nc\n",
+			new:     "a\nb\nx\nc\n",
+			context: 0,
+			want:    "@@ -2,0 +3,1 @@\n+x\n",
+		},
+		{
+			name:    "Separate hunks",
+			old:     "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			new:     "0\n2\n3\n4\n5\n6\n7\n8\n10\n",
+			context: 1,
+			want:    "@@ -1,2 +1,2 @@\n-1\n+0\n 2\n@@ -8,2 +8,2 @@\n 8\n-9\n+10\n",
+		},
+		{
+			name:    "Merged hunks",
+			old:     "1\n2\n3\n4\n",
+			new:     "0\n2\n3\n5\n",
+			context: 1,
+			want:    "@@ -1,4 +1,4 @@\n-1\n+0\n 2\n 3\n-4\n+5\n",
+		},
+		{
+			name:    "Windows line endings",
+			old:     "a\r\nb\r\n",
+			new:     "a\nb\n",
+			context: 3,
+			want:    "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := format(Unified(tt.old, tt.new, tt.context))
+
+			if got != tt.want {
+				t.Errorf("want %q; got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -4,13 +4,23 @@ import (
 	"fmt"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 	"unicode/utf8"
 )
 
 // EmailRX is a regular expression for emails as currently recommended by W3C (June 2020)
 var EmailRX = regexp.MustCompile("^[a-zA-Z0-9.!#$%&'*+\\/=?^_`{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$")
 
+// TagRX is a regular expression for tags, which are made of lower case
+// letters, digits, dots, dashes and underscores, eg. "k8s" or "node.js".
+var TagRX = regexp.MustCompile(`^[\p{Ll}\p{Nd}][\p{Ll}\p{Nd}._-]{0,31}$`)
+
+// FilenameRX is a regular expression for the names of the files of a snippet,
+// which can't contain slashes or control characters, nor be made of dots only.
+var FilenameRX = regexp.MustCompile(`^[^/\\\x00-\x1f\x7f]*[^/\\\x00-\x1f\x7f.][^/\\\x00-\x1f\x7f]*$`)
+
 // Form struct, which holds form errors and and form values.
 type Form struct {
 	url.Values
@@ -91,6 +101,139 @@ func (f *Form) PermittedValues(field string, opts ...string) {
 	f.Errors.Add(field, "This field is invalid")
 }
 
+// PermittedMultipleValues checks if every value of the given field, which may
+// be given more than once, eg. by checkboxes, has one of the given options.
+func (f *Form) PermittedMultipleValues(field string, opts ...string) {
+	for _, value := range f.Values[field] {
+		permitted := false
+		for _, opt := range opts {
+			if value == opt {
+				permitted = true
+				break
+			}
+		}
+		if !permitted {
+			f.Errors.Add(field, "This field is invalid")
+			return
+		}
+	}
+}
+
+// Contains returns true if the given value is one of the values of the given
+// field, eg. to tell which checkboxes have been checked.
+func (f *Form) Contains(field, value string) bool {
+	for _, v := range f.Values[field] {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Duration checks if the value of the given field is a duration, eg. "90m" or
+// "1h30m", which is neither shorter than min nor longer than max.
+func (f *Form) Duration(field string, min, max time.Duration) {
+	value := f.Get(field)
+	if value == "" {
+		return
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		f.Errors.Add(field, "This field is invalid")
+	} else if d < min {
+		f.Errors.Add(field, fmt.Sprintf("This field is too short (minimum is %s)", shortDuration(min)))
+	} else if d > max {
+		f.Errors.Add(field, fmt.Sprintf("This field is too long (maximum is %s)", shortDuration(max)))
+	}
+}
+
+// FutureTime checks if the value of the given field is a time in the given
+// layout which lies in the future, but no further than max from now. Times
+// without a zone are taken as UTC.
+func (f *Form) FutureTime(field, layout string, max time.Duration) {
+	value := f.Get(field)
+	if value == "" {
+		return
+	}
+
+	t, err := time.Parse(layout, value)
+	now := time.Now()
+	if err != nil {
+		f.Errors.Add(field, "This field is invalid")
+	} else if !t.After(now) {
+		f.Errors.Add(field, "This field must be in the future")
+	} else if t.After(now.Add(max)) {
+		f.Errors.Add(field, fmt.Sprintf("This field is too far in the future (maximum is %s from now)", shortDuration(max)))
+	}
+}
+
+// Integer checks if the value of the given field is a whole number which is
+// neither smaller than min nor larger than max.
+func (f *Form) Integer(field string, min, max int) {
+	value := f.Get(field)
+	if value == "" {
+		return
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		f.Errors.Add(field, "This field must be a number")
+	} else if n < min || n > max {
+		f.Errors.Add(field, fmt.Sprintf("This field must be between %d and %d", min, max))
+	}
+}
+
+// ShortDuration formats the given duration without the zero minutes and
+// seconds, eg. "10m" instead of "10m0s".
+func shortDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = s[:len(s)-2]
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = s[:len(s)-2]
+	}
+	return s
+}
+
+// List returns the trimmed, non-empty items of the comma-separated value of
+// the given field, without duplicates.
+func (f *Form) List(field string) []string {
+	items := []string{}
+	seen := map[string]bool{}
+	for _, item := range strings.Split(f.Get(field), ",") {
+		item = strings.TrimSpace(item)
+		if item == "" || seen[item] {
+			continue
+		}
+		seen[item] = true
+		items = append(items, item)
+	}
+
+	return items
+}
+
+// MaxItems checks if the comma-separated value of the given field has no more
+// than the given number of items.
+func (f *Form) MaxItems(field string, maxItems int) {
+	if len(f.List(field)) > maxItems {
+		f.Errors.Add(field, fmt.Sprintf("This field has too many items (maximum is %d)", maxItems))
+	}
+}
+
+// ItemsMatchPattern checks if every item of the comma-separated value of the
+// given field matches the given pattern.
+func (f *Form) ItemsMatchPattern(field string, pattern *regexp.Regexp) {
+	for _, item := range f.List(field) {
+		if !pattern.MatchString(item) {
+			f.Errors.Add(field, fmt.Sprintf("This field contains an invalid item: %s", item))
+			return
+		}
+	}
+}
+
 // Valid returns true if the form has no errors.
 func (f *Form) Valid() bool {
 	return len(f.Errors) == 0
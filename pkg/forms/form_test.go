@@ -0,0 +1,98 @@
+package forms
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestDuration(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"Empty", "", ""},
+		{"Minimum", "10m", ""},
+		{"Maximum", "87600h", ""},
+		{"Combined units", "1h30m", ""},
+		{"Too short", "9m59s", "This field is too short (minimum is 10m)"},
+		{"Too long", "87600h1s", "This field is too long (maximum is 87600h)"},
+		{"Negative", "-1h", "This field is too short (minimum is 10m)"},
+		{"No unit", "60", "This field is invalid"},
+		{"Unparsable", "a week", "This field is invalid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := New(url.Values{"expires": {tt.value}})
+			form.Duration("expires", 10*time.Minute, 10*365*24*time.Hour)
+
+			if got := form.Errors.Get("expires"); got != tt.want {
+				t.Errorf("want error %q; got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFutureTime(t *testing.T) {
+	const layout = "2006-01-02T15:04"
+	max := 10 * 365 * 24 * time.Hour
+	now := time.Now().UTC()
+
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"Empty", "", ""},
+		{"Past", now.Add(-time.Hour).Format(layout), "This field must be in the future"},
+		{"Now", now.Truncate(time.Minute).Format(layout), "This field must be in the future"},
+		{"Future", now.Add(time.Hour).Format(layout), ""},
+		{"Maximum", now.Add(max).Format(layout), ""},
+		{"Just over maximum", now.Add(max + 2*time.Minute).Format(layout), "This field is too far in the future (maximum is 87600h from now)"},
+		{"Far future", "9999-12-31T23:59", "This field is too far in the future (maximum is 87600h from now)"},
+		{"Other layout", now.Add(time.Hour).Format(time.RFC3339), "This field is invalid"},
+		{"Unparsable", "tomorrow", "This field is invalid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := New(url.Values{"expires_at": {tt.value}})
+			form.FutureTime("expires_at", layout, max)
+
+			if got := form.Errors.Get("expires_at"); got != tt.want {
+				t.Errorf("want error %q; got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestInteger(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"Empty", "", ""},
+		{"Minimum", "1", ""},
+		{"Maximum", "100", ""},
+		{"Surrounded by spaces", " 42 ", ""},
+		{"Too small", "0", "This field must be between 1 and 100"},
+		{"Too large", "101", "This field must be between 1 and 100"},
+		{"Negative", "-1", "This field must be between 1 and 100"},
+		{"Fraction", "1.5", "This field must be a number"},
+		{"Unparsable", "ten", "This field must be a number"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := New(url.Values{"line": {tt.value}})
+			form.Integer("line", 1, 100)
+
+			if got := form.Errors.Get("line"); got != tt.want {
+				t.Errorf("want error %q; got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package highlight
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/alecthomas/chroma"
+	"github.com/alecthomas/chroma/lexers"
+)
+
+// Rule detects a language by a pattern which has to match the content.
+type rule struct {
+	language string
+	pattern  *regexp.Regexp
+}
+
+// Rules are tried in order, so that more specific ones come first.
+var rules = []rule{
+	{"bash", regexp.MustCompile(`\A#!\S*(/|env )(ba|z)?sh\b`)},
+	{"python", regexp.MustCompile(`\A#!\S*(/|env )python`)},
+	{"javascript", regexp.MustCompile(`\A#!\S*(/|env )node\b`)},
+	{"diff", regexp.MustCompile(`(?m)^(--- \S.*\n\+\+\+ \S|@@ -\d+(,\d+)? \+\d+(,\d+)? @@)`)},
+	{"docker", regexp.MustCompile(`(?m)\A(\s*#.*\n)*\s*FROM \S+.*\n(.*\n)*\s*(RUN|COPY|ADD|CMD|ENTRYPOINT|WORKDIR|ENV) `)},
+	{"html", regexp.MustCompile(`(?i)\A\s*<(!doctype html|html|head|body|div|p|span|table|ul|form)[\s>]`)},
+	{"go", regexp.MustCompile(`(?m)^package \w+\s*$|^func (\(\w+ \*?\w+\) )?\w+\(.*\).*\{\s*$`)},
+	{"php", regexp.MustCompile(`\A\s*<\?php`)},
+	{"rust", regexp.MustCompile(`(?m)^\s*(pub )?fn \w+.*\{\s*$|^\s*let mut \w+`)},
+	{"python", regexp.MustCompile(`(?m)^(def \w+\(.*\):|class \w+(\(.*\))?:|from [\w.]+ import \w|import \w+(\.\w+)*)\s*$`)},
+	{"sql", regexp.MustCompile(`(?im)^\s*(SELECT\s(?s:.+?)\sFROM\s|INSERT INTO |UPDATE \w+ SET |DELETE FROM |CREATE (TABLE|INDEX|VIEW) |ALTER TABLE |DROP (TABLE|INDEX) |WITH \w+ AS \()`)},
+	{"c", regexp.MustCompile(`(?m)^#include <\w+\.h>`)},
+	{"cpp", regexp.MustCompile(`(?m)^#include <\w+>|std::`)},
+	{"java", regexp.MustCompile(`(?m)^\s*(public |private )?(static )?(class|interface) \w+|System\.out\.print`)},
+	{"javascript", regexp.MustCompile(`(?m)console\.log\(|^\s*(const|let|var) \w+ = (require\(|\(.*\) =>|function)|^\s*function \w+\(.*\)\s*\{`)},
+	{"makefile", regexp.MustCompile(`(?m)^[\w.-]+:.*\n\t\S`)},
+	{"yaml", regexp.MustCompile(`(?m)\A(---\s*\n)?([\w.-]+:( .*)?\n)+(\s+(- )?[\w.-]+:( .*)?\n?)*\z`)},
+	{"bash", regexp.MustCompile(`(?m)^\s*(if \[\[? .*\]\]?; then|for \w+ in .*; do|(sudo|kubectl|docker|git|cd|echo|export|curl) )`)},
+}
+
+// Detect guesses the language of the given content and returns its ID. An
+// empty ID is returned if the language is unknown.
+func Detect(content string) string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	if strings.TrimSpace(content) == "" {
+		return ""
+	}
+
+	if trimmed := strings.TrimSpace(content); strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		if json.Valid([]byte(trimmed)) {
+			return "json"
+		}
+	}
+
+	for _, r := range rules {
+		if r.pattern.MatchString(content) {
+			return r.language
+		}
+	}
+
+	// The analysers of the lexers are the last resort, as only a few lexers
+	// have one, which only has to be right most of the time.
+	if l := lexers.Analyse(content); l != nil {
+		return languageOf(l)
+	}
+
+	return ""
+}
+
+// DetectFile guesses the language of a file with the given name and content
+// and returns its ID. The language is known from the name of the file if the
+// name matches the filenames of a lexer, eg. "Dockerfile" or "*.yml", and
+// detected from its content otherwise.
+func DetectFile(filename, content string) string {
+	if l := lexers.Match(filename); l != nil {
+		if id := languageOf(l); id != "" {
+			return id
+		}
+	}
+
+	return Detect(content)
+}
+
+// LanguageOf returns the ID of the language the given lexer is for, or an
+// empty ID if it isn't for one of Languages.
+func languageOf(l chroma.Lexer) string {
+	for _, alias := range append([]string{l.Config().Name}, l.Config().Aliases...) {
+		if id := strings.ToLower(alias); isLanguage(id) {
+			return id
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,157 @@
+// Package highlight renders snippets as HTML with syntax highlighting. Tokens
+// are wrapped in spans with CSS classes, so that their colours are defined by
+// a stylesheet, which is written by WriteCSS.
+package highlight
+
+import (
+	"io"
+	"strings"
+
+	"github.com/alecthomas/chroma"
+	"github.com/alecthomas/chroma/formatters/html"
+	"github.com/alecthomas/chroma/lexers"
+	"github.com/alecthomas/chroma/styles"
+)
+
+// Language is a language snippets can be written in. Its ID is stored along
+// with a snippet and is the name of its lexer. Its extension is the one of the
+// files snippets in the language are downloaded as.
+type Language struct {
+	ID        string
+	Name      string
+	Extension string
+}
+
+// Languages lists the languages a snippet can be written in, ordered by name.
+var Languages = []Language{
+	{"bash", "Bash", ".sh"},
+	{"c", "C", ".c"},
+	{"cpp", "C++", ".cpp"},
+	{"csharp", "C#", ".cs"},
+	{"css", "CSS", ".css"},
+	{"diff", "Diff", ".diff"},
+	{"docker", "Dockerfile", ".dockerfile"},
+	{"go", "Go", ".go"},
+	{"hcl", "HCL", ".hcl"},
+	{"html", "HTML", ".html"},
+	{"java", "Java", ".java"},
+	{"javascript", "JavaScript", ".js"},
+	{"json", "JSON", ".json"},
+	{"kotlin", "Kotlin", ".kt"},
+	{"makefile", "Makefile", ".mk"},
+	{"markdown", "Markdown", ".md"},
+	{"php", "PHP", ".php"},
+	{"python", "Python", ".py"},
+	{"ruby", "Ruby", ".rb"},
+	{"rust", "Rust", ".rs"},
+	{"sql", "SQL", ".sql"},
+	{"toml", "TOML", ".toml"},
+	{"typescript", "TypeScript", ".ts"},
+	{"yaml", "YAML", ".yaml"},
+}
+
+// IDs returns the IDs of all languages.
+func IDs() []string {
+	ids := make([]string, len(Languages))
+	for i, l := range Languages {
+		ids[i] = l.ID
+	}
+	return ids
+}
+
+// IsLanguage returns true if the given ID is the ID of one of Languages.
+func isLanguage(id string) bool {
+	for _, l := range Languages {
+		if l.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Name returns the name of the language with the given ID. Snippets without a
+// known language are plain text.
+func Name(id string) string {
+	for _, l := range Languages {
+		if l.ID == id {
+			return l.Name
+		}
+	}
+	return "Plain text"
+}
+
+// Extension returns the file extension of the language with the given ID,
+// including the dot. Snippets without a known language are text files.
+func Extension(id string) string {
+	for _, l := range Languages {
+		if l.ID == id {
+			return l.Extension
+		}
+	}
+	return ".txt"
+}
+
+var formatter = html.New(html.WithClasses(true), html.PreventSurroundingPre(true))
+
+// Style is the chroma style the stylesheet is generated from.
+var style = styles.Get("github")
+
+// HTML returns the given content as HTML with syntax highlighting for the
+// language with the given ID. Content of an unknown language is escaped only.
+func HTML(content, language string) (string, error) {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	iterator, err := chroma.Coalesce(lexer(language)).Tokenise(nil, content)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	if err = formatter.Format(&b, style, iterator); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
+// Lines returns the given content as HTML with syntax highlighting just like
+// HTML does, split into the HTML of each of its lines without line breaks, so
+// that the lines can be shown apart from each other.
+func Lines(content, language string) ([]string, error) {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	tokens, err := chroma.Tokenise(chroma.Coalesce(lexer(language)), nil, content)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := chroma.SplitTokensIntoLines(tokens)
+	html := make([]string, len(lines))
+	for i, line := range lines {
+		last := &line[len(line)-1]
+		last.Value = strings.TrimSuffix(last.Value, "\n")
+
+		var b strings.Builder
+		if err = formatter.Format(&b, style, chroma.Literator(line...)); err != nil {
+			return nil, err
+		}
+		html[i] = b.String()
+	}
+
+	return html, nil
+}
+
+// Lexer returns the lexer of the language with the given ID, or the plain text
+// lexer if it's not one of Languages.
+func lexer(language string) chroma.Lexer {
+	if isLanguage(language) {
+		if l := lexers.Get(language); l != nil {
+			return l
+		}
+	}
+	return lexers.Fallback
+}
+
+// WriteCSS writes the stylesheet for the HTML returned by HTML, which has to
+// be wrapped in an element with the chroma class.
+func WriteCSS(w io.Writer) error {
+	return formatter.WriteCSS(w, style)
+}
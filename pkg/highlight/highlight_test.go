@@ -0,0 +1,169 @@
+package highlight
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/chroma/lexers"
+)
+
+func TestLanguages(t *testing.T) {
+	for _, l := range Languages {
+		if lexers.Get(l.ID) == nil {
+			t.Errorf("no lexer for language %q", l.ID)
+		}
+		if !strings.HasPrefix(l.Extension, ".") {
+			t.Errorf("no extension for language %q", l.ID)
+		}
+	}
+}
+
+func TestExtension(t *testing.T) {
+	if got := Extension("go"); got != ".go" {
+		t.Errorf("want .go; got %q", got)
+	}
+	if got := Extension(""); got != ".txt" {
+		t.Errorf("want .txt for plain text; got %q", got)
+	}
+}
+
+func TestHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		language string
+		want     []string
+	}{
+		{
+			name:     "Go",
+			content:  "func main() {}",
+			language: "go",
+			want:     []string{`<span class="kd">func</span>`, `<span class="nf">main</span>`},
+		},
+		{
+			name:     "Escaped",
+			content:  "<script>alert(1)</script>",
+			language: "",
+			want:     []string{"&lt;script&gt;"},
+		},
+		{
+			name:     "Unknown language",
+			content:  "func main() {}",
+			language: "golang-ish",
+			want:     []string{"func main() {}"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := HTML(tt.content, tt.language)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("want %q in %q", want, got)
+				}
+			}
+			if strings.Contains(got, "<pre") {
+				t.Errorf("want no surrounding pre element; got %q", got)
+			}
+		})
+	}
+}
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    int
+	}{
+		{"Empty", "", 0},
+		{"One line", "func main() {}", 1},
+		{"Trailing line break", "a\nb\n", 2},
+		{"Trailing empty line", "a\n\n", 2},
+		{"Windows line endings", "a\r\nb", 2},
+		{"Multi-line string", "s := `a\nb`\n", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Lines(tt.content, "go")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(got) != tt.want {
+				t.Fatalf("want %d lines; got %q", tt.want, got)
+			}
+			for _, line := range got {
+				if strings.ContainsAny(line, "\r\n") {
+					t.Errorf("want no line breaks in %q", line)
+				}
+			}
+		})
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"Empty", "  \n", ""},
+		{"Plain text", "Remember to restart the deployment.", ""},
+		{"Go", "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(1)\n}\n", "go"},
+		{"Go function", "func (app *application) home(w http.ResponseWriter, r *http.Request) {\n}", "go"},
+		{"SQL", "SELECT id, title\nFROM snippets WHERE expires > UTC_TIMESTAMP();", "sql"},
+		{"SQL schema", "CREATE TABLE snippets (\n    id INTEGER\n);", "sql"},
+		{"Shebang", "#!/usr/bin/env bash\nset -e\n", "bash"},
+		{"Shell commands", "kubectl get pods -n prod\nkubectl delete pod web-1", "bash"},
+		{"Python", "def f(x):\n    return x\n", "python"},
+		{"JSON", "{\"a\": 1, \"b\": [1, 2]}", "json"},
+		{"YAML", "apiVersion: v1\nkind: Pod\nmetadata:\n  name: web\n", "yaml"},
+		{"Dockerfile", "FROM golang:1.16\nRUN go build ./...\n", "docker"},
+		{"HTML", "<!doctype html>\n<html></html>", "html"},
+		{"JavaScript", "const f = () => {\n  console.log('x')\n}", "javascript"},
+		{"Diff", "--- a/main.go\n+++ b/main.go\n@@ -1 +1 @@\n-a\n+b\n", "diff"},
+		{"Windows line endings", "def f(x):\r\n    return x\r\n", "python"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Detect(tt.content)
+
+			if got != tt.want {
+				t.Errorf("want %q; got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDetectFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		content  string
+		want     string
+	}{
+		{"Dockerfile", "Dockerfile", "FROM alpine", "docker"},
+		{"Compose file", "docker-compose.yml", "services:", "yaml"},
+		{"Script", "run.sh", "docker compose up", "bash"},
+		{"Makefile", "Makefile", "all:", "makefile"},
+		{"Unknown extension", "query.txt", "SELECT id FROM snippets;", "sql"},
+		{"No filename", "", "package main\n", "go"},
+		{"Unknown language", "notes.txt", "Remember to restart the deployment.", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DetectFile(tt.filename, tt.content)
+
+			if got != tt.want {
+				t.Errorf("want %q; got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,292 @@
+// Package migrations embeds the versioned SQL migrations of every supported
+// database driver and applies them, keeping track of the applied versions in
+// the schema_migrations table.
+//
+// Migrations live in a directory per driver and are named
+// <version>_<description>.up.sql and <version>_<description>.down.sql.
+// Statements within a file are separated by a semicolon at the end of a line.
+package migrations
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed mysql/*.sql postgres/*.sql sqlite/*.sql
+var files embed.FS
+
+// ErrNoMigration is returned by Down if there is no applied migration left.
+var ErrNoMigration = errors.New("migrations: no applied migration")
+
+// Migration is a single schema change with the SQL to apply and to revert it.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status tells whether a migration has been applied and when.
+type Status struct {
+	Migration *Migration
+	Applied   time.Time
+}
+
+// Pending returns true if the migration has not been applied yet.
+func (s Status) Pending() bool {
+	return s.Applied.IsZero()
+}
+
+// Migrator applies the migrations of a driver to a database.
+type Migrator struct {
+	DB         *sql.DB
+	Driver     string
+	migrations []*Migration
+}
+
+// New loads the migrations of the given driver, which is one of mysql,
+// postgres or sqlite, and returns a migrator applying them to the given
+// database.
+func New(db *sql.DB, driver string) (*Migrator, error) {
+	migrations, err := load(driver)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{DB: db, Driver: driver, migrations: migrations}, nil
+}
+
+// Up applies all pending migrations in order of their version and returns
+// the migrations which have been applied.
+func (m *Migrator) Up() ([]*Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	done := []*Migration{}
+	for _, mig := range m.migrations {
+		if _, ok := applied[mig.Version]; ok {
+			continue
+		}
+
+		stmt := m.rebind("INSERT INTO schema_migrations (version, applied) VALUES (?, ?)")
+		if err = m.run(mig.Up, stmt, mig.Version, time.Now().UTC()); err != nil {
+			return done, fmt.Errorf("migrations: applying %d_%s: %w", mig.Version, mig.Name, err)
+		}
+		done = append(done, mig)
+	}
+
+	return done, nil
+}
+
+// Down reverts the latest applied migration and returns it. If no migration
+// has been applied, ErrNoMigration is returned.
+func (m *Migrator) Down() (*Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		mig := m.migrations[i]
+		if _, ok := applied[mig.Version]; !ok {
+			continue
+		}
+
+		stmt := m.rebind("DELETE FROM schema_migrations WHERE version = ?")
+		if err = m.run(mig.Down, stmt, mig.Version); err != nil {
+			return nil, fmt.Errorf("migrations: reverting %d_%s: %w", mig.Version, mig.Name, err)
+		}
+		return mig, nil
+	}
+
+	return nil, ErrNoMigration
+}
+
+// Status returns the status of every known migration in order of their version.
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []Status{}
+	for _, mig := range m.migrations {
+		statuses = append(statuses, Status{Migration: mig, Applied: applied[mig.Version]})
+	}
+
+	return statuses, nil
+}
+
+// Run executes the statements of a migration and the given bookkeeping
+// statement within a transaction. Note that MySQL implicitly commits most
+// schema changes, so a failed migration may be applied partially there.
+func (m *Migrator) run(migration, stmt string, args ...interface{}) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, s := range statements(migration) {
+		if _, err = tx.Exec(s); err != nil {
+			return err
+		}
+	}
+
+	if _, err = tx.Exec(stmt, args...); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Applied creates the schema_migrations table if it does not exist yet and
+// returns when each applied version has been applied.
+func (m *Migrator) applied() (map[int]time.Time, error) {
+	timestamp := "DATETIME"
+	if m.Driver == "postgres" {
+		timestamp = "TIMESTAMPTZ"
+	}
+
+	stmt := `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version BIGINT NOT NULL PRIMARY KEY,
+    applied ` + timestamp + ` NOT NULL
+)`
+	if _, err := m.DB.Exec(stmt); err != nil {
+		return nil, err
+	}
+
+	rows, err := m.DB.Query("SELECT version, applied FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]time.Time{}
+	for rows.Next() {
+		var version int
+		var t time.Time
+		if err = rows.Scan(&version, &t); err != nil {
+			return nil, err
+		}
+		applied[version] = t
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return applied, nil
+}
+
+// Rebind replaces the ? placeholders of the given statement with the numbered
+// placeholders PostgreSQL expects.
+func (m *Migrator) rebind(stmt string) string {
+	if m.Driver != "postgres" {
+		return stmt
+	}
+
+	for i := 1; strings.Contains(stmt, "?"); i++ {
+		stmt = strings.Replace(stmt, "?", "$"+strconv.Itoa(i), 1)
+	}
+
+	return stmt
+}
+
+// Load reads all migrations of the given driver and returns them ordered by
+// their version. Every migration must come with an up and a down file.
+func load(driver string) ([]*Migration, error) {
+	entries, err := fs.ReadDir(files, driver)
+	if err != nil {
+		return nil, fmt.Errorf("migrations: no migrations for driver %q", driver)
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, e := range entries {
+		name := e.Name()
+
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("migrations: unexpected file %s/%s", driver, name)
+		}
+
+		parts := strings.SplitN(strings.TrimSuffix(name, "."+direction+".sql"), "_", 2)
+		version, err := strconv.Atoi(parts[0])
+		if err != nil || len(parts) != 2 {
+			return nil, fmt.Errorf("migrations: invalid file name %s/%s", driver, name)
+		}
+
+		content, err := files.ReadFile(path.Join(driver, name))
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: parts[1]}
+			byVersion[version] = mig
+		}
+
+		if direction == "up" {
+			mig.Up = string(content)
+		} else {
+			mig.Down = string(content)
+		}
+	}
+
+	migrations := []*Migration{}
+	for _, mig := range byVersion {
+		if mig.Up == "" || mig.Down == "" {
+			return nil, fmt.Errorf("migrations: %s/%06d_%s needs an up and a down file", driver, mig.Version, mig.Name)
+		}
+		migrations = append(migrations, mig)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Statements splits the given SQL into single statements at every semicolon
+// which ends a line. Comment lines are dropped, as not every driver accepts
+// a statement which consists of a comment only.
+func statements(sql string) []string {
+	var stmts []string
+	var b strings.Builder
+
+	for _, line := range strings.Split(sql, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+
+		b.WriteString(line + "\n")
+		if strings.HasSuffix(trimmed, ";") {
+			stmts = append(stmts, strings.TrimSpace(b.String()))
+			b.Reset()
+		}
+	}
+
+	if s := strings.TrimSpace(b.String()); s != "" {
+		stmts = append(stmts, s)
+	}
+
+	return stmts
+}
@@ -0,0 +1,102 @@
+package migrations
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestStatements(t *testing.T) {
+	sql := `-- A comment
+CREATE TABLE a (
+    id INTEGER -- with a trailing comment
+);
+
+INSERT INTO a VALUES (';');
+DROP TABLE a`
+
+	want := []string{
+		"CREATE TABLE a (\n    id INTEGER -- with a trailing comment\n);",
+		"INSERT INTO a VALUES (';');",
+		"DROP TABLE a",
+	}
+
+	got := statements(sql)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %q; got %q", want, got)
+	}
+}
+
+func TestMigratorSQLite(t *testing.T) {
+	db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "test.db")+"?_foreign_keys=on")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	m, err := New(db, "sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := m.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != len(m.migrations) {
+		t.Errorf("want %d applied migrations; got %d", len(m.migrations), len(applied))
+	}
+
+	// Nothing is left to be applied the second time.
+	applied, err = m.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 0 {
+		t.Errorf("want no applied migrations; got %d", len(applied))
+	}
+
+	statuses, err := m.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range statuses {
+		if s.Pending() {
+			t.Errorf("want migration %d to be applied", s.Migration.Version)
+		}
+	}
+
+	// Every migration can be reverted and applied again.
+	for range m.migrations {
+		if _, err = m.Down(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err = m.Down(); !errors.Is(err, ErrNoMigration) {
+		t.Errorf("want %v; got %v", ErrNoMigration, err)
+	}
+	if _, err = m.Up(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	for _, driver := range []string{"mysql", "postgres", "sqlite"} {
+		t.Run(driver, func(t *testing.T) {
+			migrations, err := load(driver)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i, mig := range migrations {
+				if mig.Version != i+1 {
+					t.Errorf("want version %d; got %d", i+1, mig.Version)
+				}
+			}
+		})
+	}
+}
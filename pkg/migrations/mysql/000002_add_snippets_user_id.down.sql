@@ -0,0 +1,3 @@
+ALTER TABLE snippets DROP FOREIGN KEY snippets_fk_user_id;
+DROP INDEX idx_snippets_user_id ON snippets;
+ALTER TABLE snippets DROP COLUMN user_id;
@@ -0,0 +1,4 @@
+-- Snippets created before ownership was tracked keep a NULL user_id.
+ALTER TABLE snippets ADD COLUMN user_id INTEGER NULL;
+ALTER TABLE snippets ADD CONSTRAINT snippets_fk_user_id FOREIGN KEY (user_id) REFERENCES users(id);
+CREATE INDEX idx_snippets_user_id ON snippets(user_id);
@@ -0,0 +1,2 @@
+CREATE INDEX idx_snippets_created ON snippets(created);
+DROP INDEX idx_snippets_created_id ON snippets;
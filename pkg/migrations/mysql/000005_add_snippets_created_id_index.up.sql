@@ -0,0 +1,3 @@
+-- Snippets are paginated by their creation time and ID.
+CREATE INDEX idx_snippets_created_id ON snippets(created, id);
+DROP INDEX idx_snippets_created ON snippets;
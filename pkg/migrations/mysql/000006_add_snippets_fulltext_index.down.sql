@@ -0,0 +1 @@
+ALTER TABLE snippets DROP INDEX ft_snippets_title_content;
@@ -0,0 +1,9 @@
+CREATE TABLE tags (
+    snippet_id INTEGER NOT NULL,
+    name VARCHAR(32) NOT NULL,
+    PRIMARY KEY (snippet_id, name),
+    CONSTRAINT tags_fk_snippet_id FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE
+);
+
+-- Tag pages look up the snippets by the name of their tag.
+CREATE INDEX idx_tags_name ON tags(name, snippet_id);
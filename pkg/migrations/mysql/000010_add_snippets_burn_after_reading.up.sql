@@ -0,0 +1,5 @@
+-- Snippets which burn after reading are emptied on their first view. They are
+-- kept, marked as burned, until they expire, so that later readers can be told
+-- what happened to them.
+ALTER TABLE snippets ADD COLUMN burn_after_reading BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE snippets ADD COLUMN burned DATETIME NULL;
@@ -0,0 +1,3 @@
+-- The bcrypt hash of the password which has to be entered to see a snippet, if
+-- it's protected by one.
+ALTER TABLE snippets ADD COLUMN hashed_password CHAR(60) NULL;
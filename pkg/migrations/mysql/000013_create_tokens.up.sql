@@ -0,0 +1,16 @@
+-- Personal API tokens are stored as the SHA-256 hash of the token. Scopes are
+-- separated by spaces, and tokens which never expire have a NULL expiry time.
+CREATE TABLE tokens (
+    id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
+    user_id INTEGER NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    hashed_token CHAR(64) NOT NULL,
+    scopes VARCHAR(255) NOT NULL,
+    created DATETIME NOT NULL,
+    expires DATETIME NULL,
+    last_used DATETIME NULL,
+    CONSTRAINT tokens_uc_hashed_token UNIQUE (hashed_token),
+    CONSTRAINT tokens_fk_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_tokens_user_id ON tokens(user_id, created);
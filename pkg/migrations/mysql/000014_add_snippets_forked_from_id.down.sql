@@ -0,0 +1,3 @@
+ALTER TABLE snippets DROP FOREIGN KEY snippets_fk_forked_from_id;
+DROP INDEX idx_snippets_forked_from_id ON snippets;
+ALTER TABLE snippets DROP COLUMN forked_from_id;
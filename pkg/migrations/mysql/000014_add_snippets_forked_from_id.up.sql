@@ -0,0 +1,5 @@
+-- Forks keep the ID of the snippet they were forked from, which is set to NULL
+-- once the original is deleted.
+ALTER TABLE snippets ADD COLUMN forked_from_id INTEGER NULL;
+ALTER TABLE snippets ADD CONSTRAINT snippets_fk_forked_from_id FOREIGN KEY (forked_from_id) REFERENCES snippets(id) ON DELETE SET NULL;
+CREATE INDEX idx_snippets_forked_from_id ON snippets(forked_from_id);
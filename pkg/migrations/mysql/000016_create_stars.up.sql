@@ -0,0 +1,15 @@
+-- Every user can star a snippet only once.
+CREATE TABLE stars (
+    id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
+    snippet_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    created DATETIME NOT NULL,
+    CONSTRAINT stars_uc_snippet_id_user_id UNIQUE (snippet_id, user_id),
+    CONSTRAINT stars_fk_snippet_id FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE,
+    CONSTRAINT stars_fk_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_stars_user_id ON stars(user_id, id);
+
+-- The most starred snippets are counted over the stars given since a time.
+CREATE INDEX idx_stars_created ON stars(created);
@@ -0,0 +1,23 @@
+-- The name of the first file of a snippet as it was saved by a revision.
+ALTER TABLE snippet_revisions ADD COLUMN filename VARCHAR(100) NOT NULL DEFAULT '';
+
+-- The files after the first one as they were saved by a revision, ordered by
+-- their position.
+CREATE TABLE snippet_revision_files (
+    revision_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    filename VARCHAR(100) NOT NULL,
+    content TEXT NOT NULL,
+    PRIMARY KEY (revision_id, position),
+    CONSTRAINT snippet_revision_files_fk_revision_id FOREIGN KEY (revision_id) REFERENCES snippet_revisions(id) ON DELETE CASCADE
+);
+
+-- The latest revision of every existing snippet gets its current files.
+UPDATE snippet_revisions r
+JOIN (SELECT snippet_id, MAX(id) AS id FROM snippet_revisions GROUP BY snippet_id) latest ON latest.id = r.id
+JOIN snippets s ON s.id = r.snippet_id
+SET r.filename = s.filename;
+
+INSERT INTO snippet_revision_files (revision_id, position, filename, content)
+SELECT r.id, f.position, f.filename, f.content
+FROM snippet_files f JOIN (SELECT snippet_id, MAX(id) AS id FROM snippet_revisions GROUP BY snippet_id) r ON r.snippet_id = f.snippet_id;
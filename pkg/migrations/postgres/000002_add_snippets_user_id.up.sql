@@ -0,0 +1,3 @@
+-- Snippets created before ownership was tracked keep a NULL user_id.
+ALTER TABLE snippets ADD COLUMN user_id INTEGER NULL REFERENCES users(id);
+CREATE INDEX idx_snippets_user_id ON snippets(user_id);
@@ -0,0 +1,13 @@
+CREATE TABLE snippet_revisions (
+    id SERIAL PRIMARY KEY,
+    snippet_id INTEGER NOT NULL REFERENCES snippets(id) ON DELETE CASCADE,
+    title VARCHAR(100) NOT NULL,
+    content TEXT NOT NULL,
+    created TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_snippet_revisions_snippet_id ON snippet_revisions(snippet_id, id);
+
+-- Every existing snippet starts with its current state as the first revision.
+INSERT INTO snippet_revisions (snippet_id, title, content, created)
+SELECT id, title, content, created FROM snippets;
@@ -0,0 +1,2 @@
+-- Searches have to use the very same expression to make use of the index.
+CREATE INDEX idx_snippets_search ON snippets USING GIN (to_tsvector('simple', title || ' ' || content));
@@ -0,0 +1,8 @@
+CREATE TABLE tags (
+    snippet_id INTEGER NOT NULL REFERENCES snippets(id) ON DELETE CASCADE,
+    name VARCHAR(32) NOT NULL,
+    PRIMARY KEY (snippet_id, name)
+);
+
+-- Tag pages look up the snippets by the name of their tag.
+CREATE INDEX idx_tags_name ON tags(name, snippet_id);
@@ -0,0 +1,2 @@
+-- The ID of the language of a snippet, empty for plain text.
+ALTER TABLE snippets ADD COLUMN language VARCHAR(32) NOT NULL DEFAULT '';
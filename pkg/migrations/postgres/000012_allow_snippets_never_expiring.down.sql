@@ -0,0 +1,3 @@
+-- Snippets which never expire are kept until the end of time instead.
+UPDATE snippets SET expires = '9999-12-31 23:59:59+00' WHERE expires IS NULL;
+ALTER TABLE snippets ALTER COLUMN expires SET NOT NULL;
@@ -0,0 +1,4 @@
+-- Forks keep the ID of the snippet they were forked from, which is set to NULL
+-- once the original is deleted.
+ALTER TABLE snippets ADD COLUMN forked_from_id INTEGER NULL REFERENCES snippets(id) ON DELETE SET NULL;
+CREATE INDEX idx_snippets_forked_from_id ON snippets(forked_from_id);
@@ -0,0 +1,11 @@
+-- Comments on a whole snippet have a NULL line.
+CREATE TABLE comments (
+    id SERIAL PRIMARY KEY,
+    snippet_id INTEGER NOT NULL REFERENCES snippets(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    line INTEGER NULL,
+    content TEXT NOT NULL,
+    created TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_comments_snippet_id ON comments(snippet_id, id);
@@ -0,0 +1,13 @@
+-- The name of the first file of a snippet, whose content is the snippet's
+-- content, empty for a snippet made of a single unnamed file.
+ALTER TABLE snippets ADD COLUMN filename VARCHAR(100) NOT NULL DEFAULT '';
+
+-- The files of a snippet after the first one, ordered by their position.
+CREATE TABLE snippet_files (
+    snippet_id INTEGER NOT NULL REFERENCES snippets(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    filename VARCHAR(100) NOT NULL,
+    language VARCHAR(32) NOT NULL DEFAULT '',
+    content TEXT NOT NULL,
+    PRIMARY KEY (snippet_id, position)
+);
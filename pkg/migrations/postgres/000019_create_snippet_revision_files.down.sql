@@ -0,0 +1,2 @@
+DROP TABLE snippet_revision_files;
+ALTER TABLE snippet_revisions DROP COLUMN filename;
@@ -0,0 +1,14 @@
+-- SQLite can't drop a column which references another table, so the table is
+-- rebuilt without it.
+CREATE TABLE snippets_without_user_id (
+    id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    title VARCHAR(100) NOT NULL,
+    content TEXT NOT NULL,
+    created DATETIME NOT NULL,
+    expires DATETIME NOT NULL
+);
+INSERT INTO snippets_without_user_id (id, title, content, created, expires)
+SELECT id, title, content, created, expires FROM snippets;
+DROP TABLE snippets;
+ALTER TABLE snippets_without_user_id RENAME TO snippets;
+CREATE INDEX idx_snippets_created ON snippets(created);
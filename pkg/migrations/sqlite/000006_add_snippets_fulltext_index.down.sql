@@ -0,0 +1,5 @@
+DROP TRIGGER snippets_fts_after_insert;
+DROP TRIGGER snippets_fts_after_update;
+DROP TRIGGER snippets_fts_before_delete;
+DROP TRIGGER snippets_fts_before_update;
+DROP TABLE snippets_fts;
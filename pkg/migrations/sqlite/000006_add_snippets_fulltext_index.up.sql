@@ -0,0 +1,11 @@
+-- The full-text index reads the snippets from the snippets table and is kept
+-- up to date by triggers. Each trigger has to stay on a single line, as a
+-- semicolon at the end of a line ends the statement.
+CREATE VIRTUAL TABLE snippets_fts USING fts4(content='snippets', title, content);
+
+CREATE TRIGGER snippets_fts_before_update BEFORE UPDATE ON snippets BEGIN DELETE FROM snippets_fts WHERE docid = old.id; END;
+CREATE TRIGGER snippets_fts_before_delete BEFORE DELETE ON snippets BEGIN DELETE FROM snippets_fts WHERE docid = old.id; END;
+CREATE TRIGGER snippets_fts_after_update AFTER UPDATE ON snippets BEGIN INSERT INTO snippets_fts (docid, title, content) VALUES (new.id, new.title, new.content); END;
+CREATE TRIGGER snippets_fts_after_insert AFTER INSERT ON snippets BEGIN INSERT INTO snippets_fts (docid, title, content) VALUES (new.id, new.title, new.content); END;
+
+INSERT INTO snippets_fts (snippets_fts) VALUES ('rebuild');
@@ -0,0 +1,6 @@
+ALTER TABLE snippets ADD COLUMN visibility VARCHAR(10) NOT NULL DEFAULT 'public';
+
+-- Snippets which are not public are looked up by a random slug instead of
+-- their ID. Public snippets don't need one, so it's optional.
+ALTER TABLE snippets ADD COLUMN slug VARCHAR(32) NULL;
+CREATE UNIQUE INDEX snippets_uc_slug ON snippets(slug);
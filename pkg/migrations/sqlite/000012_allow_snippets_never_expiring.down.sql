@@ -0,0 +1,7 @@
+-- Snippets which never expire are kept until the end of time instead.
+DROP INDEX idx_snippets_expires;
+ALTER TABLE snippets RENAME COLUMN expires TO expires_null;
+ALTER TABLE snippets ADD COLUMN expires DATETIME NOT NULL DEFAULT '9999-12-31 23:59:59';
+UPDATE snippets SET expires = expires_null WHERE expires_null IS NOT NULL;
+ALTER TABLE snippets DROP COLUMN expires_null;
+CREATE INDEX idx_snippets_expires ON snippets(expires);
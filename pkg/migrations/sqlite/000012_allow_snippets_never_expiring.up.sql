@@ -0,0 +1,9 @@
+-- Snippets which never expire have a NULL expiry time. SQLite can't drop the
+-- NOT NULL constraint of a column, and rebuilding the snippets table would
+-- cascade to its revisions and tags, so the column is replaced instead.
+DROP INDEX idx_snippets_expires;
+ALTER TABLE snippets RENAME COLUMN expires TO expires_not_null;
+ALTER TABLE snippets ADD COLUMN expires DATETIME NULL;
+UPDATE snippets SET expires = expires_not_null;
+ALTER TABLE snippets DROP COLUMN expires_not_null;
+CREATE INDEX idx_snippets_expires ON snippets(expires);
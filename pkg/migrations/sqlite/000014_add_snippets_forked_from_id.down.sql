@@ -0,0 +1,3 @@
+DROP TRIGGER snippets_forked_from_after_delete;
+DROP INDEX idx_snippets_forked_from_id;
+ALTER TABLE snippets DROP COLUMN forked_from_id;
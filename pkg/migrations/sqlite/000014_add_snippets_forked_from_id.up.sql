@@ -0,0 +1,6 @@
+-- Forks keep the ID of the snippet they were forked from, which is set to NULL
+-- once the original is deleted. SQLite can't drop a column which references
+-- another table, so instead of a foreign key, a trigger forgets the original.
+ALTER TABLE snippets ADD COLUMN forked_from_id INTEGER NULL;
+CREATE INDEX idx_snippets_forked_from_id ON snippets(forked_from_id);
+CREATE TRIGGER snippets_forked_from_after_delete AFTER DELETE ON snippets BEGIN UPDATE snippets SET forked_from_id = NULL WHERE forked_from_id = old.id; END;
@@ -0,0 +1,13 @@
+-- Every user can star a snippet only once.
+CREATE TABLE stars (
+    id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    snippet_id INTEGER NOT NULL REFERENCES snippets(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created DATETIME NOT NULL,
+    CONSTRAINT stars_uc_snippet_id_user_id UNIQUE (snippet_id, user_id)
+);
+
+CREATE INDEX idx_stars_user_id ON stars(user_id, id);
+
+-- The most starred snippets are counted over the stars given since a time.
+CREATE INDEX idx_stars_created ON stars(created);
@@ -0,0 +1,2 @@
+DROP TABLE collection_snippets;
+DROP TABLE collections;
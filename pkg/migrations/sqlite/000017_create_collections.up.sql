@@ -0,0 +1,24 @@
+-- Collections which are not public are looked up by a random slug instead of
+-- their ID, just like snippets.
+CREATE TABLE collections (
+    id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    slug VARCHAR(32) NULL,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    title VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL,
+    visibility VARCHAR(10) NOT NULL DEFAULT 'public',
+    created DATETIME NOT NULL
+);
+
+CREATE UNIQUE INDEX collections_uc_slug ON collections(slug);
+CREATE INDEX idx_collections_user_id ON collections(user_id, created);
+
+-- The snippets of a collection are ordered by their position in it.
+CREATE TABLE collection_snippets (
+    collection_id INTEGER NOT NULL REFERENCES collections(id) ON DELETE CASCADE,
+    snippet_id INTEGER NOT NULL REFERENCES snippets(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (collection_id, snippet_id)
+);
+
+CREATE INDEX idx_collection_snippets_snippet_id ON collection_snippets(snippet_id);
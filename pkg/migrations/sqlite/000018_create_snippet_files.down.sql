@@ -0,0 +1,2 @@
+DROP TABLE snippet_files;
+ALTER TABLE snippets DROP COLUMN filename;
@@ -0,0 +1,178 @@
+package memory
+
+import (
+	"sort"
+
+	"jackson.software/snippetbox/pkg/models"
+)
+
+type CollectionRepository struct {
+	DB *DB
+}
+
+// Insert inserts the given collection and returns the ID of the newly created
+// collection.
+func (m *CollectionRepository) Insert(c *models.Collection) (int, error) {
+	m.DB.mu.Lock()
+	defer m.DB.mu.Unlock()
+
+	m.DB.lastCollectionID++
+	m.DB.collections[m.DB.lastCollectionID] = models.Collection{
+		ID:          m.DB.lastCollectionID,
+		Slug:        c.Slug,
+		UserID:      c.UserID,
+		Title:       c.Title,
+		Description: c.Description,
+		Visibility:  visibility(c.Visibility),
+		Created:     now(),
+	}
+
+	return m.DB.lastCollectionID, nil
+}
+
+// Delete deletes the collection with the given ID, but not its snippets. If no
+// collection could be found by the given ID, ErrNoRecord is returned.
+func (m *CollectionRepository) Delete(id int) error {
+	m.DB.mu.Lock()
+	defer m.DB.mu.Unlock()
+
+	if _, ok := m.DB.collections[id]; !ok {
+		return models.ErrNoRecord
+	}
+	delete(m.DB.collections, id)
+	delete(m.DB.collectionSnippets, id)
+
+	return nil
+}
+
+// Get returns the collection with the given ID along with the name of its
+// owner.
+func (m *CollectionRepository) Get(id int) (*models.Collection, error) {
+	m.DB.mu.RLock()
+	defer m.DB.mu.RUnlock()
+
+	c, ok := m.DB.collections[id]
+	if !ok {
+		return nil, models.ErrNoRecord
+	}
+
+	return m.DB.collectionWithAuthor(c), nil
+}
+
+// GetBySlug returns the collection with the given slug just like Get does.
+func (m *CollectionRepository) GetBySlug(slug string) (*models.Collection, error) {
+	m.DB.mu.RLock()
+	defer m.DB.mu.RUnlock()
+
+	for _, c := range m.DB.collections {
+		if slug != "" && c.Slug == slug {
+			return m.DB.collectionWithAuthor(c), nil
+		}
+	}
+
+	return nil, models.ErrNoRecord
+}
+
+// ForUser returns all collections of the user with the given ID, newest first,
+// regardless of their visibility.
+func (m *CollectionRepository) ForUser(userID int) ([]*models.Collection, error) {
+	m.DB.mu.RLock()
+	defer m.DB.mu.RUnlock()
+
+	collections := []*models.Collection{}
+	for _, c := range m.DB.collections {
+		if c.UserID == userID {
+			collections = append(collections, m.DB.collectionWithAuthor(c))
+		}
+	}
+
+	sort.Slice(collections, func(i, j int) bool {
+		if collections[i].Created.Equal(collections[j].Created) {
+			return collections[i].ID > collections[j].ID
+		}
+		return collections[i].Created.After(collections[j].Created)
+	})
+
+	return collections, nil
+}
+
+// AddSnippet adds the snippet with the given ID to the end of the collection
+// with the given ID. If the snippet is in the collection already,
+// ErrDuplicateSnippet is returned.
+func (m *CollectionRepository) AddSnippet(id, snippetID int) error {
+	m.DB.mu.Lock()
+	defer m.DB.mu.Unlock()
+
+	for _, sid := range m.DB.collectionSnippets[id] {
+		if sid == snippetID {
+			return models.ErrDuplicateSnippet
+		}
+	}
+	m.DB.collectionSnippets[id] = append(m.DB.collectionSnippets[id], snippetID)
+
+	return nil
+}
+
+// RemoveSnippet removes the snippet with the given ID from the collection with
+// the given ID. If the snippet isn't in the collection, ErrNoRecord is
+// returned.
+func (m *CollectionRepository) RemoveSnippet(id, snippetID int) error {
+	m.DB.mu.Lock()
+	defer m.DB.mu.Unlock()
+
+	n := len(m.DB.collectionSnippets[id])
+	m.DB.removeFromCollections(map[int]bool{snippetID: true}, id)
+	if len(m.DB.collectionSnippets[id]) == n {
+		return models.ErrNoRecord
+	}
+
+	return nil
+}
+
+// Reorder puts the snippets of the collection with the given ID in the order
+// of the given snippet IDs. Snippets of the collection which aren't given keep
+// their order behind the given ones, and IDs of snippets which aren't in the
+// collection are ignored.
+func (m *CollectionRepository) Reorder(id int, snippetIDs []int) error {
+	m.DB.mu.Lock()
+	defer m.DB.mu.Unlock()
+
+	if current, ok := m.DB.collectionSnippets[id]; ok {
+		m.DB.collectionSnippets[id] = models.Reorder(current, snippetIDs)
+	}
+
+	return nil
+}
+
+// CollectionWithAuthor returns a copy of the given collection with the name of
+// its owner filled in. The caller must hold the lock.
+func (db *DB) collectionWithAuthor(c models.Collection) *models.Collection {
+	c.Author = db.users[c.UserID].Name
+	return &c
+}
+
+// RemoveFromCollections removes the given deleted snippets from the
+// collections with the given IDs, or from every collection if none are given,
+// like the cascading foreign key of the SQL backends. The caller must hold the
+// lock.
+func (db *DB) removeFromCollections(deleted map[int]bool, ids ...int) {
+	if len(ids) == 0 {
+		for id := range db.collectionSnippets {
+			ids = append(ids, id)
+		}
+	}
+
+	for _, id := range ids {
+		snippetIDs, ok := db.collectionSnippets[id]
+		if !ok {
+			continue
+		}
+		kept := []int{}
+		for _, snippetID := range snippetIDs {
+			if !deleted[snippetID] {
+				kept = append(kept, snippetID)
+			}
+		}
+		db.collectionSnippets[id] = kept
+	}
+}
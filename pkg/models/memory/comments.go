@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"jackson.software/snippetbox/pkg/models"
+)
+
+type CommentRepository struct {
+	DB *DB
+}
+
+// Insert inserts the given comment and returns the ID of the newly created
+// comment. Comments with a line of 0 are on the whole snippet.
+func (m *CommentRepository) Insert(c *models.Comment) (int, error) {
+	m.DB.mu.Lock()
+	defer m.DB.mu.Unlock()
+
+	m.DB.lastCommentID++
+	m.DB.comments = append(m.DB.comments, models.Comment{
+		ID:        m.DB.lastCommentID,
+		SnippetID: c.SnippetID,
+		UserID:    c.UserID,
+		Line:      c.Line,
+		Content:   c.Content,
+		Created:   now(),
+	})
+
+	return m.DB.lastCommentID, nil
+}
+
+// ForSnippet returns all comments on the snippet with the given ID along with
+// the names of their authors, oldest first.
+func (m *CommentRepository) ForSnippet(snippetID int) ([]*models.Comment, error) {
+	m.DB.mu.RLock()
+	defer m.DB.mu.RUnlock()
+
+	comments := []*models.Comment{}
+	for i := range m.DB.comments {
+		if c := m.DB.comments[i]; c.SnippetID == snippetID {
+			c.Author = m.DB.users[c.UserID].Name
+			comments = append(comments, &c)
+		}
+	}
+
+	return comments, nil
+}
+
+// DeleteComments removes all comments for which remove returns true, like
+// the cascading foreign key of the SQL backends. The caller must hold the lock.
+func (db *DB) deleteComments(remove func(c models.Comment) bool) {
+	comments := db.comments[:0]
+	for _, c := range db.comments {
+		if !remove(c) {
+			comments = append(comments, c)
+		}
+	}
+	db.comments = comments
+}
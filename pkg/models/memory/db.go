@@ -0,0 +1,59 @@
+// Package memory implements the snippetbox repositories on top of plain Go
+// maps, so that the application can be run without a database server. All
+// data is lost when the process exits.
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"jackson.software/snippetbox/pkg/models"
+)
+
+// DB holds all records of the in-memory storage. It is shared by the
+// repositories of this package and safe for concurrent use.
+type DB struct {
+	mu        sync.RWMutex
+	snippets  map[int]models.Snippet
+	burned    map[int]bool
+	passwords map[int][]byte
+	revisions []models.Revision
+	comments  []models.Comment
+	stars     []star
+	users     map[int]models.User
+	tokens    map[int]models.Token
+	// hashedTokens holds the hashes of the tokens by their ID.
+	hashedTokens map[int]string
+	// collections holds the collections by their ID, and collectionSnippets
+	// the ordered IDs of their snippets.
+	collections        map[int]models.Collection
+	collectionSnippets map[int][]int
+
+	lastSnippetID    int
+	lastRevisionID   int
+	lastCommentID    int
+	lastCollectionID int
+	lastUserID       int
+	lastTokenID      int
+}
+
+// New creates an empty in-memory database.
+func New() *DB {
+	return &DB{
+		snippets:  map[int]models.Snippet{},
+		burned:    map[int]bool{},
+		passwords: map[int][]byte{},
+		users:     map[int]models.User{},
+		tokens:    map[int]models.Token{},
+
+		hashedTokens:       map[int]string{},
+		collections:        map[int]models.Collection{},
+		collectionSnippets: map[int][]int{},
+	}
+}
+
+// Now returns the current time in UTC with the same precision as the
+// DATETIME columns of the SQL backends.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Second)
+}
@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"jackson.software/snippetbox/pkg/models"
+)
+
+type RevisionRepository struct {
+	DB *DB
+}
+
+// ForSnippet returns all revisions of the snippet with the given ID, newest first.
+func (m *RevisionRepository) ForSnippet(snippetID int) ([]*models.Revision, error) {
+	m.DB.mu.RLock()
+	defer m.DB.mu.RUnlock()
+
+	revisions := []*models.Revision{}
+	for i := len(m.DB.revisions) - 1; i >= 0; i-- {
+		if rev := m.DB.revisions[i]; rev.SnippetID == snippetID {
+			rev.Files = copyFiles(rev.Files)
+			revisions = append(revisions, &rev)
+		}
+	}
+
+	return revisions, nil
+}
+
+// Get gets the revision found by the given ID. If the revision does not
+// belong to the snippet with the given snippet ID, an error will be returned.
+func (m *RevisionRepository) Get(snippetID, id int) (*models.Revision, error) {
+	m.DB.mu.RLock()
+	defer m.DB.mu.RUnlock()
+
+	for _, rev := range m.DB.revisions {
+		if rev.ID == id && rev.SnippetID == snippetID {
+			rev.Files = copyFiles(rev.Files)
+			return &rev, nil
+		}
+	}
+
+	return nil, models.ErrNoRecord
+}
+
+// InsertRevision records the title and files of the given snippet as a new
+// revision of the snippet with the given ID, without the languages of the
+// files. The caller must hold the lock.
+func (db *DB) insertRevision(snippetID int, s *models.Snippet) {
+	files := make([]*models.File, len(s.Files))
+	for i, f := range s.Files {
+		files[i] = &models.File{Filename: f.Filename, Content: f.Content}
+	}
+
+	db.lastRevisionID++
+	db.revisions = append(db.revisions, models.Revision{
+		ID:        db.lastRevisionID,
+		SnippetID: snippetID,
+		Title:     s.Title,
+		Filename:  s.Filename,
+		Content:   s.Content,
+		Files:     files,
+		Created:   now(),
+	})
+}
+
+// DeleteRevisions removes all revisions for which remove returns true, like
+// the cascading foreign key of the SQL backends. The caller must hold the lock.
+func (db *DB) deleteRevisions(remove func(rev models.Revision) bool) {
+	revisions := db.revisions[:0]
+	for _, rev := range db.revisions {
+		if !remove(rev) {
+			revisions = append(revisions, rev)
+		}
+	}
+	db.revisions = revisions
+}
@@ -0,0 +1,525 @@
+package memory
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/crypto/bcrypt"
+	"jackson.software/snippetbox/pkg/models"
+)
+
+type SnippetRepository struct {
+	DB *DB
+}
+
+// Insert inserts the given snippet along with its tags and files and returns
+// the ID of the newly created snippet. The snippet never expires if its expiry time is
+// zero, and its first revision is recorded along with it.
+func (m *SnippetRepository) Insert(s *models.Snippet) (int, error) {
+	hashedPassword, err := hashPassword(s.Password)
+	if err != nil {
+		return 0, err
+	}
+
+	m.DB.mu.Lock()
+	defer m.DB.mu.Unlock()
+
+	created := now()
+	m.DB.lastSnippetID++
+	snippet := models.Snippet{
+		ID:               m.DB.lastSnippetID,
+		Slug:             s.Slug,
+		UserID:           s.UserID,
+		Title:            s.Title,
+		Content:          s.Content,
+		Visibility:       visibility(s.Visibility),
+		Language:         s.Language,
+		Filename:         s.Filename,
+		Files:            copyFiles(s.Files),
+		BurnAfterReading: s.BurnAfterReading,
+		Protected:        hashedPassword != nil,
+		ForkedFromID:     s.ForkedFromID,
+		Tags:             sortedTags(s.Tags),
+		Created:          created,
+		Expires:          s.Expires,
+	}
+	m.DB.snippets[snippet.ID] = snippet
+	if hashedPassword != nil {
+		m.DB.passwords[snippet.ID] = hashedPassword
+	}
+	m.DB.insertRevision(snippet.ID, s)
+
+	return snippet.ID, nil
+}
+
+// Update replaces the slug, title, content, visibility, language, filename,
+// tags and files of the given snippet and records the new title and files as
+// a revision. The password of the snippet is replaced if a new one is given
+// and removed if the snippet is no longer protected.
+func (m *SnippetRepository) Update(s *models.Snippet) error {
+	hashedPassword, err := hashPassword(s.Password)
+	if err != nil {
+		return err
+	}
+
+	m.DB.mu.Lock()
+	defer m.DB.mu.Unlock()
+
+	snippet, ok := m.DB.snippets[s.ID]
+	if !ok {
+		return models.ErrNoRecord
+	}
+
+	snippet.Slug = s.Slug
+	snippet.Title = s.Title
+	snippet.Content = s.Content
+	snippet.Visibility = visibility(s.Visibility)
+	snippet.Language = s.Language
+	snippet.Filename = s.Filename
+	snippet.Files = copyFiles(s.Files)
+	snippet.Tags = sortedTags(s.Tags)
+	if hashedPassword != nil {
+		snippet.Protected = true
+		m.DB.passwords[s.ID] = hashedPassword
+	} else if !s.Protected {
+		snippet.Protected = false
+		delete(m.DB.passwords, s.ID)
+	}
+	m.DB.snippets[s.ID] = snippet
+	m.DB.insertRevision(s.ID, s)
+
+	return nil
+}
+
+// Delete deletes the snippet with the given ID along with its revisions. If no
+// snippet could be found by the given ID, an error will be returned.
+func (m *SnippetRepository) Delete(id int) error {
+	m.DB.mu.Lock()
+	defer m.DB.mu.Unlock()
+
+	if _, ok := m.DB.snippets[id]; !ok {
+		return models.ErrNoRecord
+	}
+	delete(m.DB.snippets, id)
+	delete(m.DB.burned, id)
+	delete(m.DB.passwords, id)
+	m.DB.deleteRevisions(func(rev models.Revision) bool { return rev.SnippetID == id })
+	m.DB.deleteComments(func(c models.Comment) bool { return c.SnippetID == id })
+	m.DB.deleteStars(func(st star) bool { return st.snippetID == id })
+	m.DB.removeFromCollections(map[int]bool{id: true})
+	m.DB.forgetOriginals(map[int]bool{id: true})
+
+	return nil
+}
+
+// DeleteExpired deletes up to limit expired snippets along with their
+// revisions and returns how many snippets have been deleted.
+func (m *SnippetRepository) DeleteExpired(limit int) (int, error) {
+	m.DB.mu.Lock()
+	defer m.DB.mu.Unlock()
+
+	t := now()
+	deleted := map[int]bool{}
+	for id, s := range m.DB.snippets {
+		if len(deleted) == limit {
+			break
+		}
+		if expired(s, t) {
+			delete(m.DB.snippets, id)
+			delete(m.DB.burned, id)
+			delete(m.DB.passwords, id)
+			deleted[id] = true
+		}
+	}
+	m.DB.deleteRevisions(func(rev models.Revision) bool { return deleted[rev.SnippetID] })
+	m.DB.deleteComments(func(c models.Comment) bool { return deleted[c.SnippetID] })
+	m.DB.deleteStars(func(st star) bool { return deleted[st.snippetID] })
+	m.DB.removeFromCollections(deleted)
+	m.DB.forgetOriginals(deleted)
+
+	return len(deleted), nil
+}
+
+// Get returns the unexpired snippet with the given ID. A snippet which burns
+// after reading is burned right away, so that only a single reader ever gets
+// to see it; everybody else gets ErrBurned.
+func (m *SnippetRepository) Get(id int) (*models.Snippet, error) {
+	m.DB.mu.Lock()
+	defer m.DB.mu.Unlock()
+
+	s, err := m.DB.peek(id)
+	if err != nil || !s.BurnAfterReading {
+		return s, err
+	}
+
+	burned := m.DB.snippets[id]
+	burned.Title = ""
+	burned.Content = ""
+	burned.Filename = ""
+	burned.Files = nil
+	m.DB.snippets[id] = burned
+	m.DB.burned[id] = true
+	m.DB.deleteRevisions(func(rev models.Revision) bool { return rev.SnippetID == id })
+
+	return s, nil
+}
+
+// Peek returns the unexpired snippet with the given ID just like Get, but never
+// burns it. If the snippet has been burned already, ErrBurned is returned.
+func (m *SnippetRepository) Peek(id int) (*models.Snippet, error) {
+	m.DB.mu.RLock()
+	defer m.DB.mu.RUnlock()
+
+	return m.DB.peek(id)
+}
+
+// PeekBySlug returns the unexpired snippet with the given slug without
+// burning it, just like Peek does.
+func (m *SnippetRepository) PeekBySlug(slug string) (*models.Snippet, error) {
+	m.DB.mu.RLock()
+	defer m.DB.mu.RUnlock()
+
+	for id, s := range m.DB.snippets {
+		if slug != "" && s.Slug == slug {
+			return m.DB.peek(id)
+		}
+	}
+
+	return nil, models.ErrNoRecord
+}
+
+// Unlock checks the given password against the one of the unexpired snippet
+// with the given ID. If the snippet isn't protected by a password, ErrNoRecord
+// is returned, and if the password doesn't match, ErrInvalidCredentials.
+func (m *SnippetRepository) Unlock(id int, password string) error {
+	m.DB.mu.RLock()
+	_, err := m.DB.peek(id)
+	hashedPassword := m.DB.passwords[id]
+	m.DB.mu.RUnlock()
+
+	if err != nil || hashedPassword == nil {
+		return models.ErrNoRecord
+	}
+
+	err = bcrypt.CompareHashAndPassword(hashedPassword, []byte(password))
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return models.ErrInvalidCredentials
+		} else {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Peek returns a copy of the unexpired snippet with the given ID, or ErrBurned
+// if it has been burned. The caller must hold the lock.
+func (db *DB) peek(id int) (*models.Snippet, error) {
+	s, ok := db.snippets[id]
+	if !ok || expired(s, now()) {
+		return nil, models.ErrNoRecord
+	}
+	if db.burned[id] {
+		return nil, models.ErrBurned
+	}
+
+	return db.withAuthor(s), nil
+}
+
+func (m *SnippetRepository) Latest() ([]*models.Snippet, error) {
+	snippets := m.filter(isPublic)
+	if len(snippets) > 10 {
+		snippets = snippets[:10]
+	}
+
+	return snippets, nil
+}
+
+// Page returns up to limit unexpired public snippets next to the given cursor,
+// newest first.
+func (m *SnippetRepository) Page(cursor models.Cursor, limit int) ([]*models.Snippet, error) {
+	snippets := m.filter(func(s *models.Snippet) bool {
+		if !isPublic(s) {
+			return false
+		}
+		if cursor.IsZero() {
+			return true
+		}
+
+		same := s.Created.Equal(cursor.Created)
+		if cursor.Newer {
+			return s.Created.After(cursor.Created) || same && s.ID > cursor.ID
+		}
+		return s.Created.Before(cursor.Created) || same && s.ID < cursor.ID
+	})
+
+	if len(snippets) > limit {
+		if cursor.Newer {
+			snippets = snippets[len(snippets)-limit:]
+		} else {
+			snippets = snippets[:limit]
+		}
+	}
+
+	return snippets, nil
+}
+
+// Search returns up to limit unexpired public snippets, which are neither
+// protected by a password nor burn after reading, whose title or content
+// contain every word of the given query, ranked by how often the words occur
+// in them.
+func (m *SnippetRepository) Search(query string, limit int) ([]*models.Snippet, error) {
+	terms := models.SearchTerms(query)
+	if len(terms) == 0 {
+		return []*models.Snippet{}, nil
+	}
+
+	scores := map[int]int{}
+	snippets := m.filter(func(s *models.Snippet) bool {
+		if !isPublic(s) || s.Protected || s.BurnAfterReading {
+			return false
+		}
+
+		words := map[string]int{}
+		for _, w := range strings.FieldsFunc(strings.ToLower(s.Title+" "+s.Content), isSeparator) {
+			words[w]++
+		}
+
+		for _, t := range terms {
+			if words[t] == 0 {
+				return false
+			}
+			scores[s.ID] += words[t]
+		}
+		return true
+	})
+
+	// The stable sort keeps snippets with the same score newest first.
+	sort.SliceStable(snippets, func(i, j int) bool {
+		return scores[snippets[i].ID] > scores[snippets[j].ID]
+	})
+	if len(snippets) > limit {
+		snippets = snippets[:limit]
+	}
+
+	return snippets, nil
+}
+
+// IsSeparator returns true if the given rune separates words, just like it
+// does for search terms.
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// ForUser returns all unexpired snippets created by the user with the given ID,
+// newest first, regardless of their visibility.
+func (m *SnippetRepository) ForUser(userID int) ([]*models.Snippet, error) {
+	return m.filter(func(s *models.Snippet) bool { return s.UserID == userID }), nil
+}
+
+// ForTag returns all unexpired public snippets tagged with the given tag, newest
+// first.
+func (m *SnippetRepository) ForTag(tag string) ([]*models.Snippet, error) {
+	return m.filter(func(s *models.Snippet) bool {
+		if !isPublic(s) {
+			return false
+		}
+		for _, t := range s.Tags {
+			if t == tag {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+// Forks returns all unexpired snippets forked from the snippet with the given
+// ID, newest first, regardless of their visibility.
+func (m *SnippetRepository) Forks(id int) ([]*models.Snippet, error) {
+	return m.filter(func(s *models.Snippet) bool { return s.ForkedFromID == id }), nil
+}
+
+// Starred returns all unexpired snippets starred by the user with the given
+// ID, most recently starred first, except for the private snippets of other
+// users.
+func (m *SnippetRepository) Starred(userID int) ([]*models.Snippet, error) {
+	m.DB.mu.RLock()
+	order := map[int]int{}
+	for i, st := range m.DB.stars {
+		if st.userID == userID {
+			order[st.snippetID] = i
+		}
+	}
+	m.DB.mu.RUnlock()
+
+	snippets := m.filter(func(s *models.Snippet) bool {
+		_, ok := order[s.ID]
+		return ok && (s.Visibility != models.VisibilityPrivate || s.UserID == userID)
+	})
+
+	sort.Slice(snippets, func(i, j int) bool {
+		return order[snippets[i].ID] > order[snippets[j].ID]
+	})
+
+	return snippets, nil
+}
+
+// MostStarred returns up to limit unexpired public snippets which have been
+// starred since the given time, or ever if it's zero, the most starred first.
+func (m *SnippetRepository) MostStarred(since time.Time, limit int) ([]*models.Snippet, error) {
+	m.DB.mu.RLock()
+	stars := map[int]int{}
+	for _, st := range m.DB.stars {
+		if !st.created.Before(since) {
+			stars[st.snippetID]++
+		}
+	}
+	m.DB.mu.RUnlock()
+
+	snippets := m.filter(func(s *models.Snippet) bool {
+		return isPublic(s) && stars[s.ID] > 0
+	})
+
+	// The snippets are sorted newest first already, which breaks the ties.
+	sort.SliceStable(snippets, func(i, j int) bool {
+		return stars[snippets[i].ID] > stars[snippets[j].ID]
+	})
+	if len(snippets) > limit {
+		snippets = snippets[:limit]
+	}
+
+	return snippets, nil
+}
+
+// ForCollection returns all unexpired snippets of the collection with the
+// given ID in their order within the collection, regardless of their
+// visibility.
+func (m *SnippetRepository) ForCollection(collectionID int) ([]*models.Snippet, error) {
+	m.DB.mu.RLock()
+	defer m.DB.mu.RUnlock()
+
+	t := now()
+	snippets := []*models.Snippet{}
+	for _, id := range m.DB.collectionSnippets[collectionID] {
+		s, ok := m.DB.snippets[id]
+		if !ok || expired(s, t) || m.DB.burned[id] {
+			continue
+		}
+		snippets = append(snippets, m.DB.withAuthor(s))
+	}
+
+	return snippets, nil
+}
+
+// Filter returns all unexpired snippets for which keep returns true, newest first.
+func (m *SnippetRepository) filter(keep func(s *models.Snippet) bool) []*models.Snippet {
+	m.DB.mu.RLock()
+	defer m.DB.mu.RUnlock()
+
+	t := now()
+	snippets := []*models.Snippet{}
+	for id, s := range m.DB.snippets {
+		if expired(s, t) || m.DB.burned[id] {
+			continue
+		}
+		if s := m.DB.withAuthor(s); keep(s) {
+			snippets = append(snippets, s)
+		}
+	}
+
+	sort.Slice(snippets, func(i, j int) bool {
+		if snippets[i].Created.Equal(snippets[j].Created) {
+			return snippets[i].ID > snippets[j].ID
+		}
+		return snippets[i].Created.After(snippets[j].Created)
+	})
+
+	return snippets
+}
+
+// ForgetOriginals clears the original of every snippet forked from one of the
+// given deleted snippets, just like the SQL databases do. The caller must hold
+// the lock.
+func (db *DB) forgetOriginals(deleted map[int]bool) {
+	for id, s := range db.snippets {
+		if deleted[s.ForkedFromID] {
+			s.ForkedFromID = 0
+			db.snippets[id] = s
+		}
+	}
+}
+
+// WithAuthor returns a copy of the given snippet with the name of its author
+// and its number of stars filled in. The caller must hold the lock.
+func (db *DB) withAuthor(s models.Snippet) *models.Snippet {
+	if u, ok := db.users[s.UserID]; ok {
+		s.Author = u.Name
+	}
+	s.Tags = append([]string{}, s.Tags...)
+	s.Files = copyFiles(s.Files)
+	s.Stars = db.countStars(s.ID)
+
+	return &s
+}
+
+// Expired returns true if the given snippet has expired at the given time.
+// Snippets without an expiry time never expire.
+func expired(s models.Snippet, t time.Time) bool {
+	return !s.Expires.IsZero() && !s.Expires.After(t)
+}
+
+// IsPublic returns true if the given snippet is listed publicly.
+func isPublic(s *models.Snippet) bool {
+	return s.Visibility == models.VisibilityPublic
+}
+
+// Visibility returns the given visibility, which defaults to public just like
+// it does in the SQL backends.
+func visibility(v models.Visibility) models.Visibility {
+	if v == "" {
+		return models.VisibilityPublic
+	}
+	return v
+}
+
+// HashPassword returns the bcrypt hash of the given snippet password, hashed
+// just like the passwords of users are, or nil if the password is empty.
+func hashPassword(password string) ([]byte, error) {
+	if password == "" {
+		return nil, nil
+	}
+
+	return bcrypt.GenerateFromPassword([]byte(password), 12)
+}
+
+// SortedTags returns a sorted copy of the given tags without duplicates, just
+// like the SQL backends return them.
+func sortedTags(tags []string) []string {
+	sorted := []string{}
+	seen := map[string]bool{}
+	for _, t := range tags {
+		if !seen[t] {
+			seen[t] = true
+			sorted = append(sorted, t)
+		}
+	}
+	sort.Strings(sorted)
+
+	return sorted
+}
+
+// CopyFiles returns a copy of the given files, so that the stored snippets
+// never share them with the callers.
+func copyFiles(files []*models.File) []*models.File {
+	copied := make([]*models.File, len(files))
+	for i, f := range files {
+		file := *f
+		copied[i] = &file
+	}
+
+	return copied
+}
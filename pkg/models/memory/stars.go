@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"time"
+)
+
+type StarRepository struct {
+	DB *DB
+}
+
+// Star is a star given by a user to a snippet. Stars are kept in the order
+// they've been given in.
+type star struct {
+	snippetID int
+	userID    int
+	created   time.Time
+}
+
+// Toggle stars the snippet with the given ID for the user with the given ID,
+// or takes the star back if the user has starred the snippet already, and
+// returns whether the snippet is starred now.
+func (m *StarRepository) Toggle(snippetID, userID int) (bool, error) {
+	m.DB.mu.Lock()
+	defer m.DB.mu.Unlock()
+
+	n := len(m.DB.stars)
+	m.DB.deleteStars(func(st star) bool { return st.snippetID == snippetID && st.userID == userID })
+	if len(m.DB.stars) < n {
+		return false, nil
+	}
+
+	m.DB.stars = append(m.DB.stars, star{snippetID: snippetID, userID: userID, created: now()})
+
+	return true, nil
+}
+
+// Exists returns whether the user with the given ID has starred the snippet
+// with the given ID.
+func (m *StarRepository) Exists(snippetID, userID int) (bool, error) {
+	m.DB.mu.RLock()
+	defer m.DB.mu.RUnlock()
+
+	for _, st := range m.DB.stars {
+		if st.snippetID == snippetID && st.userID == userID {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// CountStars returns the number of stars of the snippet with the given ID.
+// The caller must hold the lock.
+func (db *DB) countStars(snippetID int) int {
+	n := 0
+	for _, st := range db.stars {
+		if st.snippetID == snippetID {
+			n++
+		}
+	}
+	return n
+}
+
+// DeleteStars removes all stars for which remove returns true, like the
+// cascading foreign key of the SQL backends. The caller must hold the lock.
+func (db *DB) deleteStars(remove func(st star) bool) {
+	stars := db.stars[:0]
+	for _, st := range db.stars {
+		if !remove(st) {
+			stars = append(stars, st)
+		}
+	}
+	db.stars = stars
+}
@@ -0,0 +1,42 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"jackson.software/snippetbox/pkg/models/storetest"
+)
+
+func TestStores(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) *storetest.Stores {
+		db := New()
+
+		return &storetest.Stores{
+			Snippets:    &SnippetRepository{DB: db},
+			Revisions:   &RevisionRepository{DB: db},
+			Comments:    &CommentRepository{DB: db},
+			Stars:       &StarRepository{DB: db},
+			Collections: &CollectionRepository{DB: db},
+			Users:       &UserRepository{DB: db},
+			Tokens:      &TokenRepository{DB: db},
+			Expire: func(id int) error {
+				db.mu.Lock()
+				defer db.mu.Unlock()
+
+				s := db.snippets[id]
+				s.Expires = now().Add(-time.Minute)
+				db.snippets[id] = s
+				return nil
+			},
+			SetCreated: func(id int, created time.Time) error {
+				db.mu.Lock()
+				defer db.mu.Unlock()
+
+				s := db.snippets[id]
+				s.Created = created
+				db.snippets[id] = s
+				return nil
+			},
+		}
+	})
+}
@@ -0,0 +1,109 @@
+package memory
+
+import (
+	"sort"
+
+	"jackson.software/snippetbox/pkg/models"
+)
+
+type TokenRepository struct {
+	DB *DB
+}
+
+// Insert creates a new random token with the name, scopes and expiry time of
+// the given token, which never expires if its expiry time is zero. Only the
+// hash of the token is stored, so the token is returned in plaintext.
+func (m *TokenRepository) Insert(t *models.Token) (string, error) {
+	token, err := models.NewToken()
+	if err != nil {
+		return "", err
+	}
+
+	m.DB.mu.Lock()
+	defer m.DB.mu.Unlock()
+
+	m.DB.lastTokenID++
+	t.ID = m.DB.lastTokenID
+	m.DB.tokens[t.ID] = models.Token{
+		ID:      t.ID,
+		UserID:  t.UserID,
+		Name:    t.Name,
+		Scopes:  append([]string{}, t.Scopes...),
+		Created: now(),
+		Expires: t.Expires,
+	}
+	m.DB.hashedTokens[t.ID] = models.HashToken(token)
+
+	return token, nil
+}
+
+// Authenticate returns the unexpired token matching the given plaintext token
+// and records that it has been used. If there is no such token,
+// ErrInvalidCredentials is returned.
+func (m *TokenRepository) Authenticate(token string) (*models.Token, error) {
+	m.DB.mu.Lock()
+	defer m.DB.mu.Unlock()
+
+	hashedToken := models.HashToken(token)
+	t := now()
+	for id, h := range m.DB.hashedTokens {
+		if h != hashedToken {
+			continue
+		}
+
+		tok := m.DB.tokens[id]
+		if !tok.Expires.IsZero() && !tok.Expires.After(t) {
+			break
+		}
+		tok.LastUsed = t
+		m.DB.tokens[id] = tok
+
+		return copyToken(tok), nil
+	}
+
+	return nil, models.ErrInvalidCredentials
+}
+
+// ForUser returns all tokens of the user with the given ID, including expired
+// ones, newest first.
+func (m *TokenRepository) ForUser(userID int) ([]*models.Token, error) {
+	m.DB.mu.RLock()
+	defer m.DB.mu.RUnlock()
+
+	tokens := []*models.Token{}
+	for _, t := range m.DB.tokens {
+		if t.UserID == userID {
+			tokens = append(tokens, copyToken(t))
+		}
+	}
+
+	sort.Slice(tokens, func(i, j int) bool {
+		if tokens[i].Created.Equal(tokens[j].Created) {
+			return tokens[i].ID > tokens[j].ID
+		}
+		return tokens[i].Created.After(tokens[j].Created)
+	})
+
+	return tokens, nil
+}
+
+// Delete revokes the token with the given ID which belongs to the user with
+// the given ID. If the user has no such token, ErrNoRecord is returned.
+func (m *TokenRepository) Delete(userID, id int) error {
+	m.DB.mu.Lock()
+	defer m.DB.mu.Unlock()
+
+	if t, ok := m.DB.tokens[id]; !ok || t.UserID != userID {
+		return models.ErrNoRecord
+	}
+	delete(m.DB.tokens, id)
+	delete(m.DB.hashedTokens, id)
+
+	return nil
+}
+
+// CopyToken returns a copy of the given token which doesn't share its scopes.
+func copyToken(t models.Token) *models.Token {
+	t.Scopes = append([]string{}, t.Scopes...)
+	return &t
+}
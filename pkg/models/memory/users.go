@@ -0,0 +1,89 @@
+package memory
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+	"jackson.software/snippetbox/pkg/models"
+)
+
+type UserRepository struct {
+	DB *DB
+}
+
+// Insert inserts a new user. If there is already a user with the given email,
+// an error will be returned. Like the users_uc_email constraint of the MySQL
+// schema, emails are compared case-insensitively.
+func (m *UserRepository) Insert(name, email, password string) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), 12)
+	if err != nil {
+		return err
+	}
+
+	m.DB.mu.Lock()
+	defer m.DB.mu.Unlock()
+
+	for _, u := range m.DB.users {
+		if strings.EqualFold(u.Email, email) {
+			return models.ErrDuplicateEmail
+		}
+	}
+
+	m.DB.lastUserID++
+	m.DB.users[m.DB.lastUserID] = models.User{
+		ID:             m.DB.lastUserID,
+		Name:           name,
+		Email:          email,
+		HashedPassword: hashedPassword,
+		Created:        now(),
+		Active:         true,
+	}
+
+	return nil
+}
+
+// Authenticate authenticates a user. If the authentication was not successful,
+// an error will be returned.
+func (m *UserRepository) Authenticate(email, password string) (int, error) {
+	m.DB.mu.RLock()
+	var user *models.User
+	for _, u := range m.DB.users {
+		if strings.EqualFold(u.Email, email) && u.Active {
+			user = &u
+			break
+		}
+	}
+	m.DB.mu.RUnlock()
+
+	if user == nil {
+		return 0, models.ErrInvalidCredentials
+	}
+
+	err := bcrypt.CompareHashAndPassword(user.HashedPassword, []byte(password))
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return 0, models.ErrInvalidCredentials
+		} else {
+			return 0, err
+		}
+	}
+
+	return user.ID, nil
+}
+
+// Get gets a user found by the given ID. If no user could be found by
+// the given ID, an error will be returned.
+func (m *UserRepository) Get(id int) (*models.User, error) {
+	m.DB.mu.RLock()
+	defer m.DB.mu.RUnlock()
+
+	u, ok := m.DB.users[id]
+	if !ok {
+		return nil, models.ErrNoRecord
+	}
+	// Like the MySQL repository, the password hash is never handed out.
+	u.HashedPassword = nil
+
+	return &u, nil
+}
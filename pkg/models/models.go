@@ -1,22 +1,233 @@
 package models
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
 	"errors"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// Visibility controls who is able to find and see a snippet.
+type Visibility string
+
+const (
+	// VisibilityPublic snippets are listed and found by everybody.
+	VisibilityPublic Visibility = "public"
+	// VisibilityUnlisted snippets are seen by everybody who knows their slug,
+	// but are neither listed nor found by a search.
+	VisibilityUnlisted Visibility = "unlisted"
+	// VisibilityPrivate snippets are seen by their owner only.
+	VisibilityPrivate Visibility = "private"
+)
+
 var (
 	ErrNoRecord           = errors.New("models: no matching record found")
+	ErrBurned             = errors.New("models: snippet has been burned after reading")
 	ErrInvalidCredentials = errors.New("models: invalid credentials")
 	ErrDuplicateEmail     = errors.New("models: duplicate email")
+	ErrDuplicateSnippet   = errors.New("models: duplicate snippet in collection")
 )
 
 type Snippet struct {
+	ID int
+	// Slug is the random, unguessable identifier of a snippet which is not
+	// public, used in its URL in place of its ID.
+	Slug       string
+	UserID     int
+	Author     string
+	Title      string
+	Content    string
+	Visibility Visibility
+	// BurnAfterReading snippets are emptied the first time they're read.
+	BurnAfterReading bool
+	// Password, if set, is hashed and stored when the snippet is saved. It's
+	// never loaded, Protected tells whether the snippet has one.
+	Password  string
+	Protected bool
+	// Language is the ID of one of highlight.Languages or empty for plain text.
+	Language string
+	// Filename is the name of the first file of the snippet, whose content and
+	// language are Content and Language. It may be empty if the snippet is
+	// made of that file only.
+	Filename string
+	// Files are the files of the snippet after the first one, in their order.
+	Files []*File
+	// ForkedFromID is the ID of the snippet this one was forked from, or 0 if
+	// it isn't a fork or the original has been deleted.
+	ForkedFromID int
+	Tags         []string
+	// Stars is the number of users who have starred the snippet.
+	Stars   int
+	Created time.Time
+	// Expires is the zero time for snippets which never expire.
+	Expires time.Time
+}
+
+// AllFiles returns every file of the snippet, starting with the first one.
+func (s *Snippet) AllFiles() []*File {
+	files := []*File{{Filename: s.Filename, Language: s.Language, Content: s.Content}}
+
+	return append(files, s.Files...)
+}
+
+// File returns the file of the snippet with the given name, or nil if there's
+// no such file.
+func (s *Snippet) File(filename string) *File {
+	for _, f := range s.AllFiles() {
+		if f.Filename != "" && f.Filename == filename {
+			return f
+		}
+	}
+
+	return nil
+}
+
+// Path returns the URL path of the snippet, which is made of its reference.
+func (s *Snippet) Path() string {
+	return "/snippets/" + s.Ref()
+}
+
+// Ref returns the reference of the snippet, which is its ID if it's public and
+// its slug otherwise.
+func (s *Snippet) Ref() string {
+	return ref(s.ID, s.Slug, s.Visibility)
+}
+
+// Ref returns the ID of a record with the given visibility if it's public, and
+// the given slug otherwise.
+func ref(id int, slug string, v Visibility) string {
+	if v == VisibilityPublic || slug == "" {
+		return strconv.Itoa(id)
+	}
+	return slug
+}
+
+// NewSlug returns a random slug of 26 lower case letters and digits.
+func NewSlug() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return strings.ToLower(slugEncoding.EncodeToString(b)), nil
+}
+
+var slugEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// File is one of the named files a snippet is made of, like the files of a
+// gist.
+type File struct {
+	Filename string
+	// Language is the ID of one of highlight.Languages or empty for plain text.
+	Language string
+	Content  string
+}
+
+// Collection is a named group of snippets, which are kept in the order chosen
+// by the user who owns it. Collections are seen by the same rules as snippets.
+type Collection struct {
+	ID int
+	// Slug is the random, unguessable identifier of a collection which is not
+	// public, used in its URL in place of its ID.
+	Slug        string
+	UserID      int
+	Author      string
+	Title       string
+	Description string
+	Visibility  Visibility
+	Created     time.Time
+}
+
+// Path returns the URL path of the collection, which is made of its reference.
+func (c *Collection) Path() string {
+	return "/collections/" + c.Ref()
+}
+
+// Ref returns the reference of the collection, which is its ID if it's public
+// and its slug otherwise.
+func (c *Collection) Ref() string {
+	return ref(c.ID, c.Slug, c.Visibility)
+}
+
+// Reorder returns the given current order of snippet IDs changed so that the
+// given wanted IDs come first, in their order, followed by the remaining IDs
+// in their current order. Wanted IDs which aren't in the current order are
+// left out, just like duplicates.
+func Reorder(current, wanted []int) []int {
+	remaining := map[int]bool{}
+	for _, id := range current {
+		remaining[id] = true
+	}
+
+	order := make([]int, 0, len(current))
+	for _, id := range wanted {
+		if remaining[id] {
+			order = append(order, id)
+			delete(remaining, id)
+		}
+	}
+	for _, id := range current {
+		if remaining[id] {
+			order = append(order, id)
+		}
+	}
+
+	return order
+}
+
+// Cursor marks a position in the list of snippets ordered by their creation
+// time and ID, newest first. The zero cursor marks the start of the list.
+type Cursor struct {
+	Created time.Time
 	ID      int
-	Title   string
+	// Newer selects the snippets newer than the position instead of the
+	// older ones.
+	Newer bool
+}
+
+// IsZero returns true if the cursor marks the start of the list.
+func (c Cursor) IsZero() bool {
+	return c.ID == 0
+}
+
+// Revision is an immutable copy of a snippet's title and files, taken every
+// time the snippet is saved. The languages of the files aren't kept.
+type Revision struct {
+	ID        int
+	SnippetID int
+	Title     string
+	// Filename and Content are the ones of the first file of the snippet.
+	Filename string
+	Content  string
+	// Files are the files of the snippet after the first one, in their order.
+	Files   []*File
+	Created time.Time
+}
+
+// AllFiles returns every file saved by the revision, starting with the first
+// one.
+func (r *Revision) AllFiles() []*File {
+	files := []*File{{Filename: r.Filename, Content: r.Content}}
+
+	return append(files, r.Files...)
+}
+
+// Comment is a comment on a snippet, which may be attached to one of its
+// lines.
+type Comment struct {
+	ID        int
+	SnippetID int
+	UserID    int
+	Author    string
+	// Line is the number of the line the comment is attached to, starting at
+	// 1, or 0 for comments on the whole snippet.
+	Line    int
 	Content string
 	Created time.Time
-	Expires time.Time
 }
 
 type User struct {
@@ -27,3 +238,53 @@ type User struct {
 	Created        time.Time
 	Active         bool
 }
+
+// Scopes of API tokens, which limit what a token may be used for.
+const (
+	ScopeSnippetsRead  = "snippets:read"
+	ScopeSnippetsWrite = "snippets:write"
+)
+
+// Token is a personal API token, which authenticates the requests of its
+// user in place of a session. Only a hash of the token is stored, so it's
+// known in plaintext only right after it has been created.
+type Token struct {
+	ID      int
+	UserID  int
+	Name    string
+	Scopes  []string
+	Created time.Time
+	// Expires is the zero time for tokens which never expire.
+	Expires time.Time
+	// LastUsed is the zero time for tokens which haven't been used yet.
+	LastUsed time.Time
+}
+
+// HasScope returns true if the token has been granted the given scope.
+func (t *Token) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// NewToken returns a new random token made of a recognizable prefix and 32
+// lower case letters and digits.
+func NewToken() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return "sbx_" + strings.ToLower(slugEncoding.EncodeToString(b)), nil
+}
+
+// HashToken returns the hex encoded SHA-256 hash of the given token, which is
+// stored in place of the token. Unlike passwords, tokens are random and long
+// enough not to need a slow hash, so they can be looked up by their hash.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,69 @@
+package mysql
+
+import (
+	"database/sql"
+	"strings"
+
+	"jackson.software/snippetbox/pkg/models"
+)
+
+// SaveTags replaces the tags of the snippet with the given ID. It is run within
+// the transaction which saves the snippet.
+func saveTags(tx *sql.Tx, snippetID int, tags []string) error {
+	_, err := tx.Exec(`DELETE FROM tags WHERE snippet_id = ?`, snippetID)
+	if err != nil {
+		return err
+	}
+
+	stmt := `INSERT INTO tags (snippet_id, name) VALUES(?, ?)`
+
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+
+		if _, err = tx.Exec(stmt, snippetID, tag); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// LoadTags fills in the tags of the given snippets, ordered by name, with a
+// single query.
+func loadTags(db *sql.DB, snippets []*models.Snippet) error {
+	if len(snippets) == 0 {
+		return nil
+	}
+
+	args := make([]interface{}, 0, len(snippets))
+	byID := map[int]*models.Snippet{}
+	for _, s := range snippets {
+		s.Tags = []string{}
+		args = append(args, s.ID)
+		byID[s.ID] = s
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(args)), ", ")
+	stmt := `SELECT snippet_id, name FROM tags WHERE snippet_id IN (` + placeholders + `) ORDER BY name`
+
+	rows, err := db.Query(stmt, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var tag string
+		if err = rows.Scan(&id, &tag); err != nil {
+			return err
+		}
+		byID[id].Tags = append(byID[id].Tags, tag)
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,211 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+
+	"jackson.software/snippetbox/pkg/models"
+)
+
+type CollectionRepository struct {
+	DB *sql.DB
+}
+
+// Insert inserts the given collection and returns the ID of the newly created
+// collection.
+func (m *CollectionRepository) Insert(c *models.Collection) (int, error) {
+	stmt := `INSERT INTO collections (slug, user_id, title, description, visibility, created)
+    VALUES(NULLIF($1, ''), $2, $3, $4, COALESCE(NULLIF($5, ''), 'public'), now()) RETURNING id`
+
+	var id int
+	err := m.DB.QueryRow(stmt, c.Slug, c.UserID, c.Title, c.Description, c.Visibility).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// Delete deletes the collection with the given ID, but not its snippets. If no
+// collection could be found by the given ID, ErrNoRecord is returned.
+func (m *CollectionRepository) Delete(id int) error {
+	result, err := m.DB.Exec(`DELETE FROM collections WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return models.ErrNoRecord
+	}
+
+	return nil
+}
+
+// Get returns the collection with the given ID along with the name of its
+// owner.
+func (m *CollectionRepository) Get(id int) (*models.Collection, error) {
+	stmt := `SELECT c.id, COALESCE(c.slug, ''), c.user_id, u.name, c.title, c.description, c.visibility, c.created
+    FROM collections c INNER JOIN users u ON u.id = c.user_id
+    WHERE c.id = $1`
+
+	return m.queryRow(stmt, id)
+}
+
+// GetBySlug returns the collection with the given slug just like Get does.
+func (m *CollectionRepository) GetBySlug(slug string) (*models.Collection, error) {
+	stmt := `SELECT c.id, COALESCE(c.slug, ''), c.user_id, u.name, c.title, c.description, c.visibility, c.created
+    FROM collections c INNER JOIN users u ON u.id = c.user_id
+    WHERE c.slug = $1`
+
+	return m.queryRow(stmt, slug)
+}
+
+// ForUser returns all collections of the user with the given ID, newest first,
+// regardless of their visibility.
+func (m *CollectionRepository) ForUser(userID int) ([]*models.Collection, error) {
+	stmt := `SELECT c.id, COALESCE(c.slug, ''), c.user_id, u.name, c.title, c.description, c.visibility, c.created
+    FROM collections c INNER JOIN users u ON u.id = c.user_id
+    WHERE c.user_id = $1 ORDER BY c.created DESC, c.id DESC`
+
+	rows, err := m.DB.Query(stmt, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	collections := []*models.Collection{}
+
+	for rows.Next() {
+		c := &models.Collection{}
+
+		err = rows.Scan(&c.ID, &c.Slug, &c.UserID, &c.Author, &c.Title, &c.Description, &c.Visibility, &c.Created)
+		if err != nil {
+			return nil, err
+		}
+		collections = append(collections, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return collections, nil
+}
+
+// AddSnippet adds the snippet with the given ID to the end of the collection
+// with the given ID. If the snippet is in the collection already,
+// ErrDuplicateSnippet is returned.
+func (m *CollectionRepository) AddSnippet(id, snippetID int) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	var position int
+
+	stmt := `SELECT EXISTS(SELECT 1 FROM collection_snippets WHERE collection_id = $1 AND snippet_id = $2)`
+	if err = tx.QueryRow(stmt, id, snippetID).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return models.ErrDuplicateSnippet
+	}
+
+	stmt = `SELECT COALESCE(MAX(position), 0) FROM collection_snippets WHERE collection_id = $1`
+	if err = tx.QueryRow(stmt, id).Scan(&position); err != nil {
+		return err
+	}
+
+	stmt = `INSERT INTO collection_snippets (collection_id, snippet_id, position) VALUES($1, $2, $3)`
+	if _, err = tx.Exec(stmt, id, snippetID, position+1); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RemoveSnippet removes the snippet with the given ID from the collection with
+// the given ID. If the snippet isn't in the collection, ErrNoRecord is
+// returned.
+func (m *CollectionRepository) RemoveSnippet(id, snippetID int) error {
+	stmt := `DELETE FROM collection_snippets WHERE collection_id = $1 AND snippet_id = $2`
+
+	result, err := m.DB.Exec(stmt, id, snippetID)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return models.ErrNoRecord
+	}
+
+	return nil
+}
+
+// Reorder puts the snippets of the collection with the given ID in the order
+// of the given snippet IDs. Snippets of the collection which aren't given keep
+// their order behind the given ones, and IDs of snippets which aren't in the
+// collection are ignored.
+func (m *CollectionRepository) Reorder(id int, snippetIDs []int) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt := `SELECT snippet_id FROM collection_snippets WHERE collection_id = $1 ORDER BY position, snippet_id`
+
+	rows, err := tx.Query(stmt, id)
+	if err != nil {
+		return err
+	}
+	var current []int
+	for rows.Next() {
+		var snippetID int
+		if err = rows.Scan(&snippetID); err != nil {
+			rows.Close()
+			return err
+		}
+		current = append(current, snippetID)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	stmt = `UPDATE collection_snippets SET position = $1 WHERE collection_id = $2 AND snippet_id = $3`
+	for i, snippetID := range models.Reorder(current, snippetIDs) {
+		if _, err = tx.Exec(stmt, i+1, id, snippetID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// QueryRow runs the given statement and scans the returned row into a
+// collection. If there's no row, ErrNoRecord is returned.
+func (m *CollectionRepository) queryRow(stmt string, args ...interface{}) (*models.Collection, error) {
+	c := &models.Collection{}
+
+	err := m.DB.QueryRow(stmt, args...).Scan(&c.ID, &c.Slug, &c.UserID, &c.Author, &c.Title, &c.Description, &c.Visibility, &c.Created)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrNoRecord
+		} else {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
@@ -0,0 +1,58 @@
+package postgres
+
+import (
+	"database/sql"
+
+	"jackson.software/snippetbox/pkg/models"
+)
+
+type CommentRepository struct {
+	DB *sql.DB
+}
+
+// Insert inserts the given comment and returns the ID of the newly created
+// comment. Comments with a line of 0 are on the whole snippet.
+func (m *CommentRepository) Insert(c *models.Comment) (int, error) {
+	stmt := `INSERT INTO comments (snippet_id, user_id, line, content, created)
+    VALUES($1, $2, NULLIF($3, 0), $4, now()) RETURNING id`
+
+	var id int
+	err := m.DB.QueryRow(stmt, c.SnippetID, c.UserID, c.Line, c.Content).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// ForSnippet returns all comments on the snippet with the given ID along with
+// the names of their authors, oldest first.
+func (m *CommentRepository) ForSnippet(snippetID int) ([]*models.Comment, error) {
+	stmt := `SELECT c.id, c.snippet_id, c.user_id, u.name, COALESCE(c.line, 0), c.content, c.created
+    FROM comments c INNER JOIN users u ON u.id = c.user_id
+    WHERE c.snippet_id = $1 ORDER BY c.id`
+
+	rows, err := m.DB.Query(stmt, snippetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []*models.Comment{}
+
+	for rows.Next() {
+		c := &models.Comment{}
+
+		err = rows.Scan(&c.ID, &c.SnippetID, &c.UserID, &c.Author, &c.Line, &c.Content, &c.Created)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return comments, nil
+}
@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+	"jackson.software/snippetbox/pkg/models"
+)
+
+type RevisionRepository struct {
+	DB *sql.DB
+}
+
+// ForSnippet returns all revisions of the snippet with the given ID, newest first.
+func (m *RevisionRepository) ForSnippet(snippetID int) ([]*models.Revision, error) {
+	stmt := `SELECT id, snippet_id, title, filename, content, created FROM snippet_revisions
+    WHERE snippet_id = $1 ORDER BY id DESC`
+
+	rows, err := m.DB.Query(stmt, snippetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []*models.Revision{}
+
+	for rows.Next() {
+		rev := &models.Revision{}
+
+		err = rows.Scan(&rev.ID, &rev.SnippetID, &rev.Title, &rev.Filename, &rev.Content, &rev.Created)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, rev)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err = loadRevisionFiles(m.DB, revisions); err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+// Get gets the revision found by the given ID. If the revision does not
+// belong to the snippet with the given snippet ID, an error will be returned.
+func (m *RevisionRepository) Get(snippetID, id int) (*models.Revision, error) {
+	stmt := `SELECT id, snippet_id, title, filename, content, created FROM snippet_revisions
+    WHERE snippet_id = $1 AND id = $2`
+
+	rev := &models.Revision{}
+
+	err := m.DB.QueryRow(stmt, snippetID, id).Scan(&rev.ID, &rev.SnippetID, &rev.Title, &rev.Filename, &rev.Content, &rev.Created)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrNoRecord
+		} else {
+			return nil, err
+		}
+	}
+
+	if err = loadRevisionFiles(m.DB, []*models.Revision{rev}); err != nil {
+		return nil, err
+	}
+
+	return rev, nil
+}
+
+// InsertRevision records the title and files of the given snippet as a new
+// revision of the snippet with the given ID, without the languages of the
+// files. It is run within the transaction which saves the snippet, so that a
+// snippet is never stored without its revision.
+func insertRevision(tx *sql.Tx, snippetID int, s *models.Snippet) error {
+	stmt := `INSERT INTO snippet_revisions (snippet_id, title, filename, content, created)
+    VALUES($1, $2, $3, $4, now()) RETURNING id`
+
+	var id int
+	err := tx.QueryRow(stmt, snippetID, s.Title, s.Filename, s.Content).Scan(&id)
+	if err != nil {
+		return err
+	}
+
+	stmt = `INSERT INTO snippet_revision_files (revision_id, position, filename, content) VALUES($1, $2, $3, $4)`
+
+	for i, f := range s.Files {
+		if _, err = tx.Exec(stmt, id, i+1, f.Filename, f.Content); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// LoadRevisionFiles fills in the files of the given revisions which come after
+// their first one, in their order, with a single query.
+func loadRevisionFiles(db *sql.DB, revisions []*models.Revision) error {
+	if len(revisions) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(revisions))
+	byID := map[int]*models.Revision{}
+	for _, rev := range revisions {
+		rev.Files = []*models.File{}
+		ids = append(ids, int64(rev.ID))
+		byID[rev.ID] = rev
+	}
+
+	stmt := `SELECT revision_id, filename, content FROM snippet_revision_files WHERE revision_id = ANY($1) ORDER BY revision_id, position`
+
+	rows, err := db.Query(stmt, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		f := &models.File{}
+		if err = rows.Scan(&id, &f.Filename, &f.Content); err != nil {
+			return err
+		}
+		byID[id].Files = append(byID[id].Files, f)
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	"database/sql"
+
+	"github.com/lib/pq"
+	"jackson.software/snippetbox/pkg/models"
+)
+
+type StarRepository struct {
+	DB *sql.DB
+}
+
+// Toggle stars the snippet with the given ID for the user with the given ID,
+// or takes the star back if the user has starred the snippet already, and
+// returns whether the snippet is starred now.
+func (m *StarRepository) Toggle(snippetID, userID int) (bool, error) {
+	result, err := m.DB.Exec(`DELETE FROM stars WHERE snippet_id = $1 AND user_id = $2`, snippetID, userID)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if n > 0 {
+		return false, nil
+	}
+
+	// A concurrent toggle may have starred the snippet in the meantime, which
+	// the unique constraint keeps from being counted twice.
+	stmt := `INSERT INTO stars (snippet_id, user_id, created)
+    VALUES($1, $2, now())
+    ON CONFLICT DO NOTHING`
+
+	_, err = m.DB.Exec(stmt, snippetID, userID)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Exists returns whether the user with the given ID has starred the snippet
+// with the given ID.
+func (m *StarRepository) Exists(snippetID, userID int) (bool, error) {
+	var exists bool
+
+	stmt := `SELECT EXISTS(SELECT 1 FROM stars WHERE snippet_id = $1 AND user_id = $2)`
+
+	err := m.DB.QueryRow(stmt, snippetID, userID).Scan(&exists)
+	return exists, err
+}
+
+// LoadStars fills in the number of stars of the given snippets with a single
+// query.
+func loadStars(db *sql.DB, snippets []*models.Snippet) error {
+	if len(snippets) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(snippets))
+	byID := map[int]*models.Snippet{}
+	for _, s := range snippets {
+		s.Stars = 0
+		ids = append(ids, int64(s.ID))
+		byID[s.ID] = s
+	}
+
+	stmt := `SELECT snippet_id, COUNT(*) FROM stars WHERE snippet_id = ANY($1) GROUP BY snippet_id`
+
+	rows, err := db.Query(stmt, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, n int
+		if err = rows.Scan(&id, &n); err != nil {
+			return err
+		}
+		byID[id].Stars = n
+	}
+
+	return rows.Err()
+}
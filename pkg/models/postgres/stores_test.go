@@ -0,0 +1,32 @@
+package postgres
+
+import (
+	"testing"
+	"time"
+
+	"jackson.software/snippetbox/pkg/models/storetest"
+)
+
+func TestStores(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) *storetest.Stores {
+		db := newTestDB(t)
+
+		return &storetest.Stores{
+			Snippets:    &SnippetRepository{DB: db},
+			Revisions:   &RevisionRepository{DB: db},
+			Comments:    &CommentRepository{DB: db},
+			Stars:       &StarRepository{DB: db},
+			Collections: &CollectionRepository{DB: db},
+			Users:       &UserRepository{DB: db},
+			Tokens:      &TokenRepository{DB: db},
+			Expire: func(id int) error {
+				_, err := db.Exec("UPDATE snippets SET expires = now() - interval '1 minute' WHERE id = $1", id)
+				return err
+			},
+			SetCreated: func(id int, created time.Time) error {
+				_, err := db.Exec("UPDATE snippets SET created = $1 WHERE id = $2", created, id)
+				return err
+			},
+		}
+	})
+}
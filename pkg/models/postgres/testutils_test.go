@@ -0,0 +1,52 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"testing"
+
+	_ "github.com/lib/pq"
+	"jackson.software/snippetbox/pkg/migrations"
+)
+
+// NewTestDB connects to the database given by the SNIPPETBOX_TEST_POSTGRES_DSN
+// environment variable and applies all PostgreSQL migrations to it. They are
+// reverted once the test finished, so never point it at a database in use.
+// Tests are skipped if the variable is not set.
+func newTestDB(t *testing.T) *sql.DB {
+	dsn := os.Getenv("SNIPPETBOX_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("SNIPPETBOX_TEST_POSTGRES_DSN not set")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := migrations.New(db, "postgres")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = m.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		defer db.Close()
+		for {
+			_, err := m.Down()
+			if errors.Is(err, migrations.ErrNoMigration) {
+				break
+			} else if err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := db.Exec("DROP TABLE schema_migrations"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	return db
+}
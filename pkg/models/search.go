@@ -0,0 +1,35 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+)
+
+// MaxSearchTerms is the maximum number of words of a search query which are
+// taken into account.
+const MaxSearchTerms = 10
+
+// SearchTerms splits the given search query into the lower case words every
+// matching snippet has to contain. Anything but letters and digits separates
+// words, so the terms are safe to be used in any full-text query syntax.
+func SearchTerms(query string) []string {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	terms := []string{}
+	seen := map[string]bool{}
+	for _, w := range words {
+		if seen[w] {
+			continue
+		}
+		seen[w] = true
+
+		terms = append(terms, w)
+		if len(terms) == MaxSearchTerms {
+			break
+		}
+	}
+
+	return terms
+}
@@ -0,0 +1,62 @@
+package sqlite
+
+import (
+	"database/sql"
+
+	"jackson.software/snippetbox/pkg/models"
+)
+
+type CommentRepository struct {
+	DB *sql.DB
+}
+
+// Insert inserts the given comment and returns the ID of the newly created
+// comment. Comments with a line of 0 are on the whole snippet.
+func (m *CommentRepository) Insert(c *models.Comment) (int, error) {
+	stmt := `INSERT INTO comments (snippet_id, user_id, line, content, created)
+    VALUES(?, ?, NULLIF(?, 0), ?, datetime('now'))`
+
+	result, err := m.DB.Exec(stmt, c.SnippetID, c.UserID, c.Line, c.Content)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+// ForSnippet returns all comments on the snippet with the given ID along with
+// the names of their authors, oldest first.
+func (m *CommentRepository) ForSnippet(snippetID int) ([]*models.Comment, error) {
+	stmt := `SELECT c.id, c.snippet_id, c.user_id, u.name, COALESCE(c.line, 0), c.content, c.created
+    FROM comments c INNER JOIN users u ON u.id = c.user_id
+    WHERE c.snippet_id = ? ORDER BY c.id`
+
+	rows, err := m.DB.Query(stmt, snippetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []*models.Comment{}
+
+	for rows.Next() {
+		c := &models.Comment{}
+
+		err = rows.Scan(&c.ID, &c.SnippetID, &c.UserID, &c.Author, &c.Line, &c.Content, &c.Created)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return comments, nil
+}
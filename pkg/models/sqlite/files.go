@@ -0,0 +1,64 @@
+package sqlite
+
+import (
+	"database/sql"
+	"strings"
+
+	"jackson.software/snippetbox/pkg/models"
+)
+
+// SaveFiles replaces the files of the snippet with the given ID which come
+// after its first one. It is run within the transaction which saves the
+// snippet.
+func saveFiles(tx *sql.Tx, snippetID int, files []*models.File) error {
+	_, err := tx.Exec(`DELETE FROM snippet_files WHERE snippet_id = ?`, snippetID)
+	if err != nil {
+		return err
+	}
+
+	stmt := `INSERT INTO snippet_files (snippet_id, position, filename, language, content) VALUES(?, ?, ?, ?, ?)`
+
+	for i, f := range files {
+		if _, err = tx.Exec(stmt, snippetID, i+1, f.Filename, f.Language, f.Content); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// LoadFiles fills in the files of the given snippets which come after their
+// first one, in their order, with a single query.
+func loadFiles(db *sql.DB, snippets []*models.Snippet) error {
+	if len(snippets) == 0 {
+		return nil
+	}
+
+	args := make([]interface{}, 0, len(snippets))
+	byID := map[int]*models.Snippet{}
+	for _, s := range snippets {
+		s.Files = []*models.File{}
+		args = append(args, s.ID)
+		byID[s.ID] = s
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(args)), ", ")
+	stmt := `SELECT snippet_id, filename, language, content FROM snippet_files WHERE snippet_id IN (` + placeholders + `) ORDER BY snippet_id, position`
+
+	rows, err := db.Query(stmt, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		f := &models.File{}
+		if err = rows.Scan(&id, &f.Filename, &f.Language, &f.Content); err != nil {
+			return err
+		}
+		byID[id].Files = append(byID[id].Files, f)
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,528 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"jackson.software/snippetbox/pkg/models"
+)
+
+type SnippetRepository struct {
+	DB *sql.DB
+}
+
+// Insert inserts the given snippet along with its tags and files and returns
+// the ID of the newly created snippet. The snippet never expires if its expiry time is
+// zero, and its first revision is recorded within the same transaction.
+func (m *SnippetRepository) Insert(s *models.Snippet) (int, error) {
+	hashedPassword, err := hashPassword(s.Password)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt := `INSERT INTO snippets (slug, user_id, title, content, visibility, language, filename, burn_after_reading, hashed_password, forked_from_id, created, expires)
+    VALUES(NULLIF(?, ''), ?, ?, ?, COALESCE(NULLIF(?, ''), 'public'), ?, ?, ?, ?, NULLIF(?, 0), datetime('now'), ?)`
+
+	result, err := tx.Exec(stmt, s.Slug, s.UserID, s.Title, s.Content, s.Visibility, s.Language, s.Filename, s.BurnAfterReading, hashedPassword, s.ForkedFromID, nullTime(s.Expires))
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	if err = insertRevision(tx, int(id), s); err != nil {
+		return 0, err
+	}
+
+	if err = saveTags(tx, int(id), s.Tags); err != nil {
+		return 0, err
+	}
+
+	if err = saveFiles(tx, int(id), s.Files); err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+// Update replaces the slug, title, content, visibility, language, filename,
+// tags and files of the given snippet and records the new title and files as a revision
+// within the same transaction. The password of the snippet is replaced if a
+// new one is given and removed if the snippet is no longer protected.
+func (m *SnippetRepository) Update(s *models.Snippet) error {
+	hashedPassword, err := hashPassword(s.Password)
+	if err != nil {
+		return err
+	}
+
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt := `UPDATE snippets SET slug = NULLIF(?, ''), title = ?, content = ?, visibility = COALESCE(NULLIF(?, ''), 'public'), language = ?, filename = ? WHERE id = ?`
+
+	result, err := tx.Exec(stmt, s.Slug, s.Title, s.Content, s.Visibility, s.Language, s.Filename, s.ID)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return models.ErrNoRecord
+	}
+
+	if s.Password != "" || !s.Protected {
+		_, err = tx.Exec(`UPDATE snippets SET hashed_password = ? WHERE id = ?`, hashedPassword, s.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err = insertRevision(tx, s.ID, s); err != nil {
+		return err
+	}
+
+	if err = saveTags(tx, s.ID, s.Tags); err != nil {
+		return err
+	}
+
+	if err = saveFiles(tx, s.ID, s.Files); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Delete deletes the snippet with the given ID. If no snippet could be found
+// by the given ID, an error will be returned.
+func (m *SnippetRepository) Delete(id int) error {
+	stmt := `DELETE FROM snippets WHERE id = ?`
+
+	result, err := m.DB.Exec(stmt, id)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return models.ErrNoRecord
+	}
+
+	return nil
+}
+
+// DeleteExpired deletes up to limit expired snippets along with their
+// revisions and returns how many snippets have been deleted.
+func (m *SnippetRepository) DeleteExpired(limit int) (int, error) {
+	stmt := `DELETE FROM snippets WHERE id IN (
+        SELECT id FROM snippets WHERE expires <= datetime('now') LIMIT ?
+    )`
+
+	result, err := m.DB.Exec(stmt, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(n), nil
+}
+
+// Get returns the unexpired snippet with the given ID. A snippet which burns
+// after reading is burned within a transaction, so that only a single reader
+// ever gets to see it; everybody else gets ErrBurned.
+func (m *SnippetRepository) Get(id int) (*models.Snippet, error) {
+	s, err := m.Peek(id)
+	if err != nil || !s.BurnAfterReading {
+		return s, err
+	}
+
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err = burn(tx, s.ID); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Peek returns the unexpired snippet with the given ID just like Get, but never
+// burns it. If the snippet has been burned already, ErrBurned is returned.
+func (m *SnippetRepository) Peek(id int) (*models.Snippet, error) {
+	stmt := `SELECT s.id, COALESCE(s.slug, ''), COALESCE(s.user_id, 0), COALESCE(u.name, ''), s.title, s.content, s.visibility, s.language, s.filename, s.burn_after_reading, s.hashed_password IS NOT NULL, COALESCE(s.forked_from_id, 0), s.created, s.expires, s.burned IS NOT NULL
+    FROM snippets s LEFT JOIN users u ON u.id = s.user_id
+    WHERE (s.expires IS NULL OR s.expires > datetime('now')) AND s.id = ?`
+
+	row := m.DB.QueryRow(stmt, id)
+	s := &models.Snippet{}
+	var expires sql.NullTime
+	var burned bool
+
+	err := row.Scan(&s.ID, &s.Slug, &s.UserID, &s.Author, &s.Title, &s.Content, &s.Visibility, &s.Language, &s.Filename, &s.BurnAfterReading, &s.Protected, &s.ForkedFromID, &s.Created, &expires, &burned)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrNoRecord
+		} else {
+			return nil, err
+		}
+	}
+	if burned {
+		return nil, models.ErrBurned
+	}
+	s.Expires = expires.Time
+
+	if err = loadTags(m.DB, []*models.Snippet{s}); err != nil {
+		return nil, err
+	}
+
+	if err = loadStars(m.DB, []*models.Snippet{s}); err != nil {
+		return nil, err
+	}
+
+	if err = loadFiles(m.DB, []*models.Snippet{s}); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// PeekBySlug returns the unexpired snippet with the given slug without
+// burning it, just like Peek does.
+func (m *SnippetRepository) PeekBySlug(slug string) (*models.Snippet, error) {
+	stmt := `SELECT s.id, COALESCE(s.slug, ''), COALESCE(s.user_id, 0), COALESCE(u.name, ''), s.title, s.content, s.visibility, s.language, s.filename, s.burn_after_reading, s.hashed_password IS NOT NULL, COALESCE(s.forked_from_id, 0), s.created, s.expires, s.burned IS NOT NULL
+    FROM snippets s LEFT JOIN users u ON u.id = s.user_id
+    WHERE (s.expires IS NULL OR s.expires > datetime('now')) AND s.slug = ?`
+
+	row := m.DB.QueryRow(stmt, slug)
+	s := &models.Snippet{}
+	var expires sql.NullTime
+	var burned bool
+
+	err := row.Scan(&s.ID, &s.Slug, &s.UserID, &s.Author, &s.Title, &s.Content, &s.Visibility, &s.Language, &s.Filename, &s.BurnAfterReading, &s.Protected, &s.ForkedFromID, &s.Created, &expires, &burned)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrNoRecord
+		} else {
+			return nil, err
+		}
+	}
+	if burned {
+		return nil, models.ErrBurned
+	}
+	s.Expires = expires.Time
+
+	if err = loadTags(m.DB, []*models.Snippet{s}); err != nil {
+		return nil, err
+	}
+
+	if err = loadStars(m.DB, []*models.Snippet{s}); err != nil {
+		return nil, err
+	}
+
+	if err = loadFiles(m.DB, []*models.Snippet{s}); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Unlock checks the given password against the one of the unexpired snippet
+// with the given ID. If the snippet isn't protected by a password, ErrNoRecord
+// is returned, and if the password doesn't match, ErrInvalidCredentials.
+func (m *SnippetRepository) Unlock(id int, password string) error {
+	var hashedPassword []byte
+
+	stmt := `SELECT hashed_password FROM snippets
+    WHERE (expires IS NULL OR expires > datetime('now')) AND burned IS NULL AND hashed_password IS NOT NULL AND id = ?`
+
+	err := m.DB.QueryRow(stmt, id).Scan(&hashedPassword)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.ErrNoRecord
+		} else {
+			return err
+		}
+	}
+
+	err = bcrypt.CompareHashAndPassword(hashedPassword, []byte(password))
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return models.ErrInvalidCredentials
+		} else {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *SnippetRepository) Latest() ([]*models.Snippet, error) {
+	stmt := `SELECT s.id, COALESCE(s.slug, ''), COALESCE(s.user_id, 0), COALESCE(u.name, ''), s.title, s.content, s.visibility, s.language, s.filename, s.burn_after_reading, s.hashed_password IS NOT NULL, COALESCE(s.forked_from_id, 0), s.created, s.expires
+    FROM snippets s LEFT JOIN users u ON u.id = s.user_id
+    WHERE (s.expires IS NULL OR s.expires > datetime('now')) AND s.burned IS NULL AND s.visibility = 'public' ORDER BY s.created DESC LIMIT 10`
+
+	return m.query(stmt)
+}
+
+// Page returns up to limit unexpired public snippets next to the given cursor,
+// newest first.
+func (m *SnippetRepository) Page(cursor models.Cursor, limit int) ([]*models.Snippet, error) {
+	stmt := `SELECT s.id, COALESCE(s.slug, ''), COALESCE(s.user_id, 0), COALESCE(u.name, ''), s.title, s.content, s.visibility, s.language, s.filename, s.burn_after_reading, s.hashed_password IS NOT NULL, COALESCE(s.forked_from_id, 0), s.created, s.expires
+    FROM snippets s LEFT JOIN users u ON u.id = s.user_id
+    WHERE (s.expires IS NULL OR s.expires > datetime('now')) AND s.burned IS NULL AND s.visibility = 'public'`
+
+	if cursor.IsZero() {
+		return m.query(stmt+` ORDER BY s.created DESC, s.id DESC LIMIT ?`, limit)
+	}
+
+	// SQLite compares the creation times as text, so the cursor's time has to
+	// be formatted just like datetime('now') does.
+	created := cursor.Created.UTC().Format("2006-01-02 15:04:05")
+
+	if !cursor.Newer {
+		stmt += ` AND (s.created < ? OR (s.created = ? AND s.id < ?))
+    ORDER BY s.created DESC, s.id DESC LIMIT ?`
+
+		return m.query(stmt, created, created, cursor.ID, limit)
+	}
+
+	// Newer snippets are selected oldest first, so that the ones closest to
+	// the cursor are returned, and reversed afterwards.
+	stmt += ` AND (s.created > ? OR (s.created = ? AND s.id > ?))
+    ORDER BY s.created, s.id LIMIT ?`
+
+	snippets, err := m.query(stmt, created, created, cursor.ID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(snippets)-1; i < j; i, j = i+1, j-1 {
+		snippets[i], snippets[j] = snippets[j], snippets[i]
+	}
+
+	return snippets, nil
+}
+
+// Search returns up to limit unexpired public snippets, which are neither
+// protected by a password nor burn after reading, whose title or content
+// contain every word of the given query, most relevant first. FTS4 has no
+// ranking function, so snippets are ranked by how often the words occur in
+// them, which is the number of offsets(), each of which is made up of four
+// numbers.
+func (m *SnippetRepository) Search(query string, limit int) ([]*models.Snippet, error) {
+	terms := models.SearchTerms(query)
+	if len(terms) == 0 {
+		return []*models.Snippet{}, nil
+	}
+
+	// Quoted terms are never taken for operators and all of them are required.
+	match := `"` + strings.Join(terms, `" "`) + `"`
+
+	stmt := `SELECT s.id, COALESCE(s.slug, ''), COALESCE(s.user_id, 0), COALESCE(u.name, ''), s.title, s.content, s.visibility, s.language, s.filename, s.burn_after_reading, s.hashed_password IS NOT NULL, COALESCE(s.forked_from_id, 0), s.created, s.expires
+    FROM snippets_fts INNER JOIN snippets s ON s.id = snippets_fts.docid
+    LEFT JOIN users u ON u.id = s.user_id
+    WHERE snippets_fts MATCH ? AND (s.expires IS NULL OR s.expires > datetime('now')) AND s.burned IS NULL AND s.hashed_password IS NULL AND s.burn_after_reading = FALSE AND s.visibility = 'public'
+    ORDER BY length(offsets(snippets_fts)) - length(replace(offsets(snippets_fts), ' ', '')) DESC, s.created DESC
+    LIMIT ?`
+
+	return m.query(stmt, match, limit)
+}
+
+// ForUser returns all unexpired snippets created by the user with the given ID,
+// newest first, regardless of their visibility.
+func (m *SnippetRepository) ForUser(userID int) ([]*models.Snippet, error) {
+	stmt := `SELECT s.id, COALESCE(s.slug, ''), s.user_id, u.name, s.title, s.content, s.visibility, s.language, s.filename, s.burn_after_reading, s.hashed_password IS NOT NULL, COALESCE(s.forked_from_id, 0), s.created, s.expires
+    FROM snippets s INNER JOIN users u ON u.id = s.user_id
+    WHERE (s.expires IS NULL OR s.expires > datetime('now')) AND s.burned IS NULL AND s.user_id = ? ORDER BY s.created DESC`
+
+	return m.query(stmt, userID)
+}
+
+// ForTag returns all unexpired public snippets tagged with the given tag, newest
+// first.
+func (m *SnippetRepository) ForTag(tag string) ([]*models.Snippet, error) {
+	stmt := `SELECT s.id, COALESCE(s.slug, ''), COALESCE(s.user_id, 0), COALESCE(u.name, ''), s.title, s.content, s.visibility, s.language, s.filename, s.burn_after_reading, s.hashed_password IS NOT NULL, COALESCE(s.forked_from_id, 0), s.created, s.expires
+    FROM snippets s INNER JOIN tags t ON t.snippet_id = s.id LEFT JOIN users u ON u.id = s.user_id
+    WHERE (s.expires IS NULL OR s.expires > datetime('now')) AND s.burned IS NULL AND s.visibility = 'public' AND t.name = ? ORDER BY s.created DESC`
+
+	return m.query(stmt, tag)
+}
+
+// Forks returns all unexpired snippets forked from the snippet with the given
+// ID, newest first, regardless of their visibility.
+func (m *SnippetRepository) Forks(id int) ([]*models.Snippet, error) {
+	stmt := `SELECT s.id, COALESCE(s.slug, ''), COALESCE(s.user_id, 0), COALESCE(u.name, ''), s.title, s.content, s.visibility, s.language, s.filename, s.burn_after_reading, s.hashed_password IS NOT NULL, COALESCE(s.forked_from_id, 0), s.created, s.expires
+    FROM snippets s LEFT JOIN users u ON u.id = s.user_id
+    WHERE (s.expires IS NULL OR s.expires > datetime('now')) AND s.burned IS NULL AND s.forked_from_id = ? ORDER BY s.created DESC, s.id DESC`
+
+	return m.query(stmt, id)
+}
+
+// Starred returns all unexpired snippets starred by the user with the given
+// ID, most recently starred first, except for the private snippets of other
+// users.
+func (m *SnippetRepository) Starred(userID int) ([]*models.Snippet, error) {
+	stmt := `SELECT s.id, COALESCE(s.slug, ''), COALESCE(s.user_id, 0), COALESCE(u.name, ''), s.title, s.content, s.visibility, s.language, s.filename, s.burn_after_reading, s.hashed_password IS NOT NULL, COALESCE(s.forked_from_id, 0), s.created, s.expires
+    FROM stars st INNER JOIN snippets s ON s.id = st.snippet_id LEFT JOIN users u ON u.id = s.user_id
+    WHERE (s.expires IS NULL OR s.expires > datetime('now')) AND s.burned IS NULL AND (s.visibility <> 'private' OR s.user_id = st.user_id) AND st.user_id = ?
+    ORDER BY st.id DESC`
+
+	return m.query(stmt, userID)
+}
+
+// MostStarred returns up to limit unexpired public snippets which have been
+// starred since the given time, or ever if it's zero, the most starred first.
+func (m *SnippetRepository) MostStarred(since time.Time, limit int) ([]*models.Snippet, error) {
+	stmt := `SELECT s.id, COALESCE(s.slug, ''), COALESCE(s.user_id, 0), COALESCE(u.name, ''), s.title, s.content, s.visibility, s.language, s.filename, s.burn_after_reading, s.hashed_password IS NOT NULL, COALESCE(s.forked_from_id, 0), s.created, s.expires
+    FROM snippets s INNER JOIN (
+        SELECT snippet_id, COUNT(*) AS stars FROM stars WHERE created >= COALESCE(?, created) GROUP BY snippet_id
+    ) st ON st.snippet_id = s.id
+    LEFT JOIN users u ON u.id = s.user_id
+    WHERE (s.expires IS NULL OR s.expires > datetime('now')) AND s.burned IS NULL AND s.visibility = 'public'
+    ORDER BY st.stars DESC, s.created DESC, s.id DESC LIMIT ?`
+
+	return m.query(stmt, nullTime(since), limit)
+}
+
+// ForCollection returns all unexpired snippets of the collection with the
+// given ID in their order within the collection, regardless of their
+// visibility.
+func (m *SnippetRepository) ForCollection(collectionID int) ([]*models.Snippet, error) {
+	stmt := `SELECT s.id, COALESCE(s.slug, ''), COALESCE(s.user_id, 0), COALESCE(u.name, ''), s.title, s.content, s.visibility, s.language, s.filename, s.burn_after_reading, s.hashed_password IS NOT NULL, COALESCE(s.forked_from_id, 0), s.created, s.expires
+    FROM collection_snippets cs INNER JOIN snippets s ON s.id = cs.snippet_id LEFT JOIN users u ON u.id = s.user_id
+    WHERE (s.expires IS NULL OR s.expires > datetime('now')) AND s.burned IS NULL AND cs.collection_id = ?
+    ORDER BY cs.position, cs.snippet_id`
+
+	return m.query(stmt, collectionID)
+}
+
+// HashPassword returns the bcrypt hash of the given snippet password, hashed
+// just like the passwords of users are, or nil if the password is empty.
+func hashPassword(password string) (interface{}, error) {
+	if password == "" {
+		return nil, nil
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), 12)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(hashedPassword), nil
+}
+
+// NullTime returns nil for the zero time, which is stored as NULL, and the
+// given time formatted just like the times SQLite returns otherwise.
+func nullTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC().Format("2006-01-02 15:04:05")
+}
+
+// Burn empties the snippet with the given ID, deletes its revisions and its
+// files after the first one, and marks it as burned. The snippet is only
+// updated if it hasn't been burned yet, so of two concurrent readers only one
+// succeeds and the other gets ErrBurned.
+func burn(tx *sql.Tx, id int) error {
+	stmt := `UPDATE snippets SET title = '', content = '', filename = '', burned = datetime('now')
+    WHERE id = ? AND burned IS NULL`
+
+	result, err := tx.Exec(stmt, id)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return models.ErrBurned
+	}
+
+	_, err = tx.Exec(`DELETE FROM snippet_revisions WHERE snippet_id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`DELETE FROM snippet_files WHERE snippet_id = ?`, id)
+	return err
+}
+
+// Query runs the given statement and scans every returned row, along with the
+// snippet's tags and files, into a snippet.
+func (m *SnippetRepository) query(stmt string, args ...interface{}) ([]*models.Snippet, error) {
+	rows, err := m.DB.Query(stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snippets := []*models.Snippet{}
+
+	for rows.Next() {
+		s := &models.Snippet{}
+		var expires sql.NullTime
+
+		err = rows.Scan(&s.ID, &s.Slug, &s.UserID, &s.Author, &s.Title, &s.Content, &s.Visibility, &s.Language, &s.Filename, &s.BurnAfterReading, &s.Protected, &s.ForkedFromID, &s.Created, &expires)
+		if err != nil {
+			return nil, err
+		}
+		s.Expires = expires.Time
+		snippets = append(snippets, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err = loadTags(m.DB, snippets); err != nil {
+		return nil, err
+	}
+
+	if err = loadStars(m.DB, snippets); err != nil {
+		return nil, err
+	}
+
+	if err = loadFiles(m.DB, snippets); err != nil {
+		return nil, err
+	}
+
+	return snippets, nil
+}
@@ -0,0 +1,124 @@
+package sqlite
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"jackson.software/snippetbox/pkg/models"
+)
+
+type TokenRepository struct {
+	DB *sql.DB
+}
+
+// Insert creates a new random token with the name, scopes and expiry time of
+// the given token, which never expires if its expiry time is zero. Only the
+// hash of the token is stored, so the token is returned in plaintext.
+func (m *TokenRepository) Insert(t *models.Token) (string, error) {
+	token, err := models.NewToken()
+	if err != nil {
+		return "", err
+	}
+
+	stmt := `INSERT INTO tokens (user_id, name, hashed_token, scopes, created, expires)
+    VALUES(?, ?, ?, ?, datetime('now'), ?)`
+
+	result, err := m.DB.Exec(stmt, t.UserID, t.Name, models.HashToken(token), strings.Join(t.Scopes, " "), nullTime(t.Expires))
+	if err != nil {
+		return "", err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return "", err
+	}
+	t.ID = int(id)
+
+	return token, nil
+}
+
+// Authenticate returns the unexpired token matching the given plaintext token
+// and records that it has been used. If there is no such token,
+// ErrInvalidCredentials is returned.
+func (m *TokenRepository) Authenticate(token string) (*models.Token, error) {
+	stmt := `SELECT id, user_id, name, scopes, created, expires, last_used FROM tokens
+    WHERE (expires IS NULL OR expires > datetime('now')) AND hashed_token = ?`
+
+	tokens, err := m.query(stmt, models.HashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, models.ErrInvalidCredentials
+	}
+
+	used := time.Now().UTC().Truncate(time.Second)
+	_, err = m.DB.Exec(`UPDATE tokens SET last_used = ? WHERE id = ?`, nullTime(used), tokens[0].ID)
+	if err != nil {
+		return nil, err
+	}
+	tokens[0].LastUsed = used
+
+	return tokens[0], nil
+}
+
+// ForUser returns all tokens of the user with the given ID, including expired
+// ones, newest first.
+func (m *TokenRepository) ForUser(userID int) ([]*models.Token, error) {
+	stmt := `SELECT id, user_id, name, scopes, created, expires, last_used FROM tokens
+    WHERE user_id = ? ORDER BY created DESC, id DESC`
+
+	return m.query(stmt, userID)
+}
+
+// Delete revokes the token with the given ID which belongs to the user with
+// the given ID. If the user has no such token, ErrNoRecord is returned.
+func (m *TokenRepository) Delete(userID, id int) error {
+	result, err := m.DB.Exec(`DELETE FROM tokens WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return models.ErrNoRecord
+	}
+
+	return nil
+}
+
+// Query runs the given statement and scans every returned row into a token.
+func (m *TokenRepository) query(stmt string, args ...interface{}) ([]*models.Token, error) {
+	rows, err := m.DB.Query(stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []*models.Token{}
+
+	for rows.Next() {
+		t := &models.Token{}
+		var scopes string
+		var expires, lastUsed sql.NullTime
+
+		err = rows.Scan(&t.ID, &t.UserID, &t.Name, &scopes, &t.Created, &expires, &lastUsed)
+		if err != nil {
+			return nil, err
+		}
+		t.Scopes = strings.Fields(scopes)
+		t.Expires = expires.Time
+		t.LastUsed = lastUsed.Time
+		tokens = append(tokens, t)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
@@ -0,0 +1,76 @@
+package models
+
+import "time"
+
+// SnippetStore is implemented by every storage backend which is able to
+// persist snippets.
+type SnippetStore interface {
+	Insert(s *Snippet) (int, error)
+	Update(s *Snippet) error
+	Delete(id int) error
+	DeleteExpired(limit int) (int, error)
+	Get(id int) (*Snippet, error)
+	Peek(id int) (*Snippet, error)
+	PeekBySlug(slug string) (*Snippet, error)
+	Unlock(id int, password string) error
+	Latest() ([]*Snippet, error)
+	Page(cursor Cursor, limit int) ([]*Snippet, error)
+	Search(query string, limit int) ([]*Snippet, error)
+	ForUser(userID int) ([]*Snippet, error)
+	ForTag(tag string) ([]*Snippet, error)
+	Forks(id int) ([]*Snippet, error)
+	Starred(userID int) ([]*Snippet, error)
+	MostStarred(since time.Time, limit int) ([]*Snippet, error)
+	ForCollection(collectionID int) ([]*Snippet, error)
+}
+
+// RevisionStore is implemented by every storage backend which is able to
+// read the revisions recorded when snippets are saved.
+type RevisionStore interface {
+	ForSnippet(snippetID int) ([]*Revision, error)
+	Get(snippetID, id int) (*Revision, error)
+}
+
+// CommentStore is implemented by every storage backend which is able to
+// persist comments on snippets.
+type CommentStore interface {
+	Insert(c *Comment) (int, error)
+	ForSnippet(snippetID int) ([]*Comment, error)
+}
+
+// StarStore is implemented by every storage backend which is able to
+// persist the stars users give to snippets.
+type StarStore interface {
+	Toggle(snippetID, userID int) (bool, error)
+	Exists(snippetID, userID int) (bool, error)
+}
+
+// CollectionStore is implemented by every storage backend which is able to
+// persist collections of snippets.
+type CollectionStore interface {
+	Insert(c *Collection) (int, error)
+	Delete(id int) error
+	Get(id int) (*Collection, error)
+	GetBySlug(slug string) (*Collection, error)
+	ForUser(userID int) ([]*Collection, error)
+	AddSnippet(id, snippetID int) error
+	RemoveSnippet(id, snippetID int) error
+	Reorder(id int, snippetIDs []int) error
+}
+
+// UserStore is implemented by every storage backend which is able to
+// persist users.
+type UserStore interface {
+	Insert(name, email, password string) error
+	Authenticate(email, password string) (int, error)
+	Get(id int) (*User, error)
+}
+
+// TokenStore is implemented by every storage backend which is able to
+// persist API tokens.
+type TokenStore interface {
+	Insert(t *Token) (string, error)
+	Authenticate(token string) (*Token, error)
+	ForUser(userID int) ([]*Token, error)
+	Delete(userID, id int) error
+}
@@ -0,0 +1,92 @@
+package storetest
+
+import (
+	"errors"
+	"testing"
+
+	"jackson.software/snippetbox/pkg/models"
+)
+
+func testCollectionRepository(t *testing.T, s *Stores) {
+	snippets := s.Snippets
+	m := s.Collections
+	insertUsers(t, s, "Alice")
+
+	for _, snippet := range []*models.Snippet{
+		{UserID: 1, Title: "Connect", Content: "psql -h db"},
+		{UserID: 1, Title: "Tunnel", Content: "ssh -L 5432:db:5432 bastion"},
+		{UserID: 1, Title: "Password", Content: "x", Visibility: models.VisibilityPrivate},
+	} {
+		if _, err := snippets.Insert(snippet); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, c := range []*models.Collection{
+		{UserID: 1, Title: "Onboarding: database access", Description: "Start here"},
+		{UserID: 1, Slug: "abc", Title: "Drafts", Visibility: models.VisibilityUnlisted},
+	} {
+		if _, err := m.Insert(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c, err := m.Get(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Author != "Alice" || c.Visibility != models.VisibilityPublic || c.Description != "Start here" || c.Created.IsZero() {
+		t.Errorf("want public collection by Alice; got %+v", c)
+	}
+	if c, err = m.GetBySlug("abc"); err != nil || c.ID != 2 {
+		t.Errorf("want collection 2 by its slug; got %+v, %v", c, err)
+	}
+	if _, err = m.Get(99); !errors.Is(err, models.ErrNoRecord) {
+		t.Errorf("want ErrNoRecord; got %v", err)
+	}
+	if collections, err := m.ForUser(1); err != nil || len(collections) != 2 || collections[0].ID != 2 {
+		t.Errorf("want both collections, newest first; got %v, %v", collections, err)
+	}
+
+	for _, id := range []int{1, 2, 3} {
+		if err = m.AddSnippet(1, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = m.AddSnippet(1, 2); !errors.Is(err, models.ErrDuplicateSnippet) {
+		t.Errorf("want ErrDuplicateSnippet; got %v", err)
+	}
+	list, err := snippets.ForCollection(1)
+	assertIDs(t, "after adding", list, err, 1, 2, 3)
+
+	if err = m.Reorder(1, []int{3, 1, 99}); err != nil {
+		t.Fatal(err)
+	}
+	list, err = snippets.ForCollection(1)
+	assertIDs(t, "after reordering", list, err, 3, 1, 2)
+
+	if err = m.RemoveSnippet(1, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err = m.RemoveSnippet(1, 1); !errors.Is(err, models.ErrNoRecord) {
+		t.Errorf("want ErrNoRecord; got %v", err)
+	}
+	list, err = snippets.ForCollection(1)
+	assertIDs(t, "after removing", list, err, 3, 2)
+
+	if err = snippets.Delete(3); err != nil {
+		t.Fatal(err)
+	}
+	list, err = snippets.ForCollection(1)
+	assertIDs(t, "after deleting a snippet", list, err, 2)
+
+	if err = m.Delete(1); err != nil {
+		t.Fatal(err)
+	}
+	if err = m.Delete(1); !errors.Is(err, models.ErrNoRecord) {
+		t.Errorf("want ErrNoRecord; got %v", err)
+	}
+	if snippet, err := snippets.Peek(2); err != nil || snippet.ID != 2 {
+		t.Errorf("want snippets to outlive their collection; got %v", err)
+	}
+}
@@ -0,0 +1,48 @@
+package storetest
+
+import (
+	"testing"
+
+	"jackson.software/snippetbox/pkg/models"
+)
+
+func testCommentRepository(t *testing.T, s *Stores) {
+	snippets := s.Snippets
+	m := s.Comments
+	insertUsers(t, s, "Alice")
+
+	id, err := snippets.Insert(&models.Snippet{UserID: 1, Title: "Runbook", Content: "systemctl stop api\nsystemctl start api"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []*models.Comment{
+		{SnippetID: id, UserID: 1, Content: "Looks good"},
+		{SnippetID: id, UserID: 1, Line: 2, Content: "Why not restart?"},
+	} {
+		if _, err = m.Insert(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	comments, err := m.ForSnippet(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != 2 {
+		t.Fatalf("want 2 comments; got %d", len(comments))
+	}
+	if c := comments[0]; c.Line != 0 || c.Author != "Alice" || c.Content != "Looks good" {
+		t.Errorf("want comment on the whole snippet by Alice first; got %+v", c)
+	}
+	if c := comments[1]; c.Line != 2 || c.Created.IsZero() {
+		t.Errorf("want comment on line 2; got %+v", c)
+	}
+
+	if err = snippets.Delete(id); err != nil {
+		t.Fatal(err)
+	}
+	if comments, err = m.ForSnippet(id); err != nil || len(comments) != 0 {
+		t.Errorf("want comments to be deleted along with their snippet; got %d, %v", len(comments), err)
+	}
+}
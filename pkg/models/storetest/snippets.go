@@ -0,0 +1,643 @@
+package storetest
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"jackson.software/snippetbox/pkg/models"
+)
+
+func testSnippetRepositoryGet(t *testing.T, s *Stores) {
+	m := s.Snippets
+	insertUsers(t, s, "Alice")
+
+	expires := time.Now().UTC().AddDate(0, 0, 7).Truncate(time.Second)
+	id, err := m.Insert(&models.Snippet{UserID: 1, Title: "Title", Content: "Content", Language: "go", Expires: expires})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snippet, err := m.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snippet.Title != "Title" || snippet.Content != "Content" || snippet.Language != "go" || snippet.UserID != 1 || snippet.Author != "Alice" || !snippet.Expires.Equal(expires) {
+		t.Errorf("want snippet by Alice expiring in 7 days; got %+v", snippet)
+	}
+
+	if err = s.Expire(id); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = m.Get(id)
+	if !errors.Is(err, models.ErrNoRecord) {
+		t.Errorf("want %v for expired snippet; got %v", models.ErrNoRecord, err)
+	}
+
+	latest, err := m.Latest()
+	assertIDs(t, "latest snippets", latest, err)
+}
+
+func testSnippetRepositoryLatest(t *testing.T, s *Stores) {
+	m := s.Snippets
+	insertUsers(t, s, "Alice")
+
+	for i := 0; i < 12; i++ {
+		if _, err := m.Insert(&models.Snippet{UserID: 1, Title: "Title", Content: "Content"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	latest, err := m.Latest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(latest) != 10 {
+		t.Fatalf("want 10 snippets; got %d", len(latest))
+	}
+	if latest[0].ID != 12 || latest[9].ID != 3 {
+		t.Errorf("want snippets 12 to 3; got %d to %d", latest[0].ID, latest[9].ID)
+	}
+}
+
+func testSnippetRepositoryUpdate(t *testing.T, s *Stores) {
+	m := s.Snippets
+	insertUsers(t, s, "Alice")
+
+	id, err := m.Insert(&models.Snippet{UserID: 1, Title: "Title", Content: "Content"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = m.Update(&models.Snippet{ID: id, Title: "New title", Content: "New content"}); err != nil {
+		t.Fatal(err)
+	}
+
+	revs, err := s.Revisions.ForSnippet(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revs) != 2 || revs[0].Content != "New content" || revs[1].Content != "Content" {
+		t.Errorf("want the new and the original revision; got %+v", revs)
+	}
+
+	if err = m.Delete(id); err != nil {
+		t.Fatal(err)
+	}
+	if err = m.Update(&models.Snippet{ID: id, Title: "Title", Content: "Content"}); !errors.Is(err, models.ErrNoRecord) {
+		t.Errorf("want %v for deleted snippet; got %v", models.ErrNoRecord, err)
+	}
+}
+
+func testSnippetRepositoryDelete(t *testing.T, s *Stores) {
+	m := s.Snippets
+	insertUsers(t, s, "Alice")
+
+	id, err := m.Insert(&models.Snippet{UserID: 1, Title: "Title", Content: "Content"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = m.Delete(id); err != nil {
+		t.Fatal(err)
+	}
+	if err = m.Delete(id); !errors.Is(err, models.ErrNoRecord) {
+		t.Errorf("want %v; got %v", models.ErrNoRecord, err)
+	}
+
+	revs, err := s.Revisions.ForSnippet(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revs) != 0 {
+		t.Errorf("want revisions to be deleted with the snippet; got %d", len(revs))
+	}
+}
+
+func testSnippetRepositoryDeleteExpired(t *testing.T, s *Stores) {
+	m := s.Snippets
+	insertUsers(t, s, "Alice")
+
+	for i := 0; i < 5; i++ {
+		if _, err := m.Insert(&models.Snippet{UserID: 1, Title: "Title", Content: "Content"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Expire all snippets but the last one.
+	for id := 1; id <= 4; id++ {
+		if err := s.Expire(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, want := range []int{3, 1, 0} {
+		n, err := m.DeleteExpired(3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != want {
+			t.Errorf("want %d deleted snippets; got %d", want, n)
+		}
+	}
+
+	for id := 1; id <= 5; id++ {
+		revs, err := s.Revisions.ForSnippet(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := id / 5; len(revs) != want {
+			t.Errorf("want %d revisions of snippet %d; got %d", want, id, len(revs))
+		}
+	}
+
+	latest, err := m.Latest()
+	assertIDs(t, "left", latest, err, 5)
+}
+
+func testSnippetRepositoryPage(t *testing.T, s *Stores) {
+	m := s.Snippets
+	insertUsers(t, s, "Alice")
+
+	for i := 0; i < 5; i++ {
+		if _, err := m.Insert(&models.Snippet{UserID: 1, Title: "Title", Content: "Content"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Snippets 2 and 3 have been created at the same time.
+	created := map[int]time.Time{}
+	for id := 1; id <= 5; id++ {
+		created[id] = time.Date(2020, 12, 17, 10, 0, id-id/3, 0, time.UTC)
+		if err := s.SetCreated(id, created[id]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	at := func(id int, newer bool) models.Cursor {
+		return models.Cursor{Created: created[id], ID: id, Newer: newer}
+	}
+
+	tests := []struct {
+		name   string
+		cursor models.Cursor
+		want   []int
+	}{
+		{"Start", models.Cursor{}, []int{5, 4}},
+		{"Older", at(4, false), []int{3, 2}},
+		{"Older with same time", at(3, false), []int{2, 1}},
+		{"End", at(1, false), []int{}},
+		{"Newer", at(1, true), []int{3, 2}},
+		{"Newer with same time", at(2, true), []int{4, 3}},
+		{"Newest", at(4, true), []int{5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snippets, err := m.Page(tt.cursor, 2)
+			assertIDs(t, "snippets", snippets, err, tt.want...)
+		})
+	}
+}
+
+func testSnippetRepositorySearch(t *testing.T, s *Stores) {
+	m := s.Snippets
+	insertUsers(t, s, "Alice")
+
+	for _, snippet := range []struct{ title, content string }{
+		{"Go channels", "Use a buffered channel."},
+		{"Shell loops", "for f in *; do echo $f; done"},
+		{"Channel select", "select on a channel or a timeout channel"},
+	} {
+		if _, err := m.Insert(&models.Snippet{UserID: 1, Title: snippet.title, Content: snippet.content}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.Update(&models.Snippet{ID: 2, Title: "Shell loops", Content: "while true; do echo channel; done"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Delete(2); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []int
+	}{
+		{"Ranked by relevance", "channel", []int{3, 1}},
+		{"Every word required", "buffered CHANNEL", []int{1}},
+		{"Operators are words", `channel OR "loops`, []int{}},
+		{"Deleted snippets", "echo", []int{}},
+		{"Empty", " *? ", []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snippets, err := m.Search(tt.query, 10)
+			assertIDs(t, "snippets", snippets, err, tt.want...)
+		})
+	}
+}
+
+func testSnippetRepositoryTags(t *testing.T, s *Stores) {
+	m := s.Snippets
+	insertUsers(t, s, "Alice")
+
+	id, err := m.Insert(&models.Snippet{UserID: 1, Title: "Title", Content: "Content", Tags: []string{"sql", "k8s", "sql"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = m.Insert(&models.Snippet{UserID: 1, Title: "Untagged", Content: "Content"}); err != nil {
+		t.Fatal(err)
+	}
+
+	snippet, err := m.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(snippet.Tags, []string{"k8s", "sql"}) {
+		t.Errorf("want sorted tags without duplicates; got %q", snippet.Tags)
+	}
+
+	latest, err := m.Latest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(latest) != 2 || len(latest[0].Tags) != 0 || len(latest[1].Tags) != 2 {
+		t.Errorf("want tags of the latest snippets; got %+v", latest)
+	}
+
+	snippet.Tags = []string{"oncall"}
+	if err = m.Update(snippet); err != nil {
+		t.Fatal(err)
+	}
+
+	for tag, want := range map[string]int{"oncall": 1, "sql": 0} {
+		snippets, err := m.ForTag(tag)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(snippets) != want {
+			t.Errorf("want %d snippets tagged %q; got %d", want, tag, len(snippets))
+		}
+	}
+}
+
+func testSnippetRepositoryFiles(t *testing.T, s *Stores) {
+	m := s.Snippets
+	insertUsers(t, s, "Alice")
+
+	files := []*models.File{
+		{Filename: "docker-compose.yml", Language: "yaml", Content: "services:"},
+		{Filename: "run.sh", Language: "bash", Content: "docker compose up"},
+	}
+	id, err := m.Insert(&models.Snippet{UserID: 1, Title: "Title", Content: "FROM alpine", Language: "docker", Filename: "Dockerfile", Files: files})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snippet, err := m.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := append([]*models.File{{Filename: "Dockerfile", Language: "docker", Content: "FROM alpine"}}, files...)
+	if !reflect.DeepEqual(snippet.AllFiles(), want) {
+		t.Errorf("want files %+v; got %+v", want, snippet.AllFiles())
+	}
+
+	latest, err := m.Latest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(latest) != 1 || len(latest[0].Files) != 2 {
+		t.Errorf("want files of the latest snippets; got %+v", latest)
+	}
+
+	snippet.Filename = ""
+	snippet.Files = files[1:]
+	if err = m.Update(snippet); err != nil {
+		t.Fatal(err)
+	}
+
+	snippet, err = m.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snippet.Filename != "" || !reflect.DeepEqual(snippet.Files, files[1:]) {
+		t.Errorf("want updated files %+v; got %q and %+v", files[1:], snippet.Filename, snippet.Files)
+	}
+
+	revs, err := s.Revisions.ForSnippet(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revs) != 2 {
+		t.Fatalf("want 2 revisions; got %d", len(revs))
+	}
+	want = []*models.File{{Content: "FROM alpine"}, {Filename: "run.sh", Content: "docker compose up"}}
+	if !reflect.DeepEqual(revs[0].AllFiles(), want) {
+		t.Errorf("want files %+v in the new revision; got %+v", want, revs[0].AllFiles())
+	}
+
+	rev, err := s.Revisions.Get(id, revs[1].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []*models.File{
+		{Filename: "Dockerfile", Content: "FROM alpine"},
+		{Filename: "docker-compose.yml", Content: "services:"},
+		{Filename: "run.sh", Content: "docker compose up"},
+	}
+	if !reflect.DeepEqual(rev.AllFiles(), want) {
+		t.Errorf("want files %+v in the original revision; got %+v", want, rev.AllFiles())
+	}
+}
+
+func testSnippetRepositoryVisibility(t *testing.T, s *Stores) {
+	m := s.Snippets
+	insertUsers(t, s, "Alice")
+
+	for _, snippet := range []*models.Snippet{
+		{UserID: 1, Title: "Public", Content: "Incident notes", Tags: []string{"oncall"}},
+		{Slug: "unlisted-slug", UserID: 1, Title: "Unlisted", Content: "Incident notes", Visibility: models.VisibilityUnlisted, Tags: []string{"oncall"}},
+		{Slug: "private-slug", UserID: 1, Title: "Private", Content: "Incident notes", Visibility: models.VisibilityPrivate, Tags: []string{"oncall"}},
+	} {
+		if _, err := m.Insert(snippet); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lists := map[string]func() ([]*models.Snippet, error){
+		"latest": m.Latest,
+		"page":   func() ([]*models.Snippet, error) { return m.Page(models.Cursor{}, 10) },
+		"search": func() ([]*models.Snippet, error) { return m.Search("incident", 10) },
+		"tag":    func() ([]*models.Snippet, error) { return m.ForTag("oncall") },
+	}
+	for name, list := range lists {
+		snippets, err := list()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(snippets) != 1 || snippets[0].Visibility != models.VisibilityPublic {
+			t.Errorf("want only the public snippet in the %s list; got %+v", name, snippets)
+		}
+	}
+
+	snippets, err := m.ForUser(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snippets) != 3 {
+		t.Errorf("want all 3 snippets of the user; got %d", len(snippets))
+	}
+
+	snippet, err := m.PeekBySlug("private-slug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snippet.Title != "Private" || snippet.Visibility != models.VisibilityPrivate || snippet.Slug != "private-slug" {
+		t.Errorf("want the private snippet; got %+v", snippet)
+	}
+
+	if _, err = m.PeekBySlug("missing-slug"); !errors.Is(err, models.ErrNoRecord) {
+		t.Errorf("want ErrNoRecord for an unknown slug; got %v", err)
+	}
+
+	snippet.Visibility = models.VisibilityPublic
+	if err = m.Update(snippet); err != nil {
+		t.Fatal(err)
+	}
+
+	latest, err := m.Latest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(latest) != 2 {
+		t.Errorf("want the snippet made public to be listed; got %d snippets", len(latest))
+	}
+}
+
+func testSnippetRepositoryBurnAfterReading(t *testing.T, s *Stores) {
+	m := s.Snippets
+	insertUsers(t, s, "Alice")
+
+	id, err := m.Insert(&models.Snippet{UserID: 1, Title: "Credentials", Content: "hunter2", BurnAfterReading: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := m.Search("hunter2", 10)
+	assertIDs(t, "snippets found before the snippet is read", found, err)
+
+	for i := 0; i < 2; i++ {
+		snippet, err := m.Peek(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if snippet.Content != "hunter2" || !snippet.BurnAfterReading {
+			t.Errorf("want to peek at the snippet; got %+v", snippet)
+		}
+	}
+
+	snippet, err := m.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snippet.Content != "hunter2" {
+		t.Errorf("want the content on the first read; got %q", snippet.Content)
+	}
+
+	if _, err = m.Get(id); !errors.Is(err, models.ErrBurned) {
+		t.Errorf("want ErrBurned on the second read; got %v", err)
+	}
+	if _, err = m.Peek(id); !errors.Is(err, models.ErrBurned) {
+		t.Errorf("want ErrBurned when peeking at a burned snippet; got %v", err)
+	}
+
+	revs, err := s.Revisions.ForSnippet(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revs) != 0 {
+		t.Errorf("want the revisions of a burned snippet to be deleted; got %d", len(revs))
+	}
+
+	latest, err := m.Latest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(latest) != 0 {
+		t.Errorf("want no burned snippets in the latest snippets; got %d", len(latest))
+	}
+
+	id, err = m.Insert(&models.Snippet{UserID: 1, Title: "Credentials", Content: "hunter2", BurnAfterReading: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errs := make(chan error)
+	for i := 0; i < 5; i++ {
+		go func() {
+			_, err := m.Get(id)
+			errs <- err
+		}()
+	}
+
+	read := 0
+	for i := 0; i < 5; i++ {
+		if err := <-errs; err == nil {
+			read++
+		} else if !errors.Is(err, models.ErrBurned) {
+			t.Errorf("want ErrBurned for concurrent readers; got %v", err)
+		}
+	}
+	if read != 1 {
+		t.Errorf("want exactly one concurrent reader to read the snippet; got %d", read)
+	}
+}
+
+func testSnippetRepositoryPassword(t *testing.T, s *Stores) {
+	m := s.Snippets
+	insertUsers(t, s, "Alice")
+
+	id, err := m.Insert(&models.Snippet{UserID: 1, Title: "Incident notes", Content: "Contractor notes", Password: "pa55word123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = m.Insert(&models.Snippet{UserID: 1, Title: "Open", Content: "Contractor notes"}); err != nil {
+		t.Fatal(err)
+	}
+
+	snippet, err := m.Peek(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !snippet.Protected || snippet.Password != "" {
+		t.Errorf("want a protected snippet without its password; got %+v", snippet)
+	}
+
+	if err = m.Unlock(id, "wrong password"); !errors.Is(err, models.ErrInvalidCredentials) {
+		t.Errorf("want ErrInvalidCredentials for a wrong password; got %v", err)
+	}
+	if err = m.Unlock(id, "pa55word123"); err != nil {
+		t.Errorf("want the snippet to be unlocked; got %v", err)
+	}
+	if err = m.Unlock(id+1, "pa55word123"); !errors.Is(err, models.ErrNoRecord) {
+		t.Errorf("want ErrNoRecord for a snippet without password; got %v", err)
+	}
+
+	found, err := m.Search("contractor", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found[0].Protected {
+		t.Errorf("want only the unprotected snippet to be found; got %+v", found)
+	}
+
+	// The password is kept as long as the snippet is protected.
+	if err = m.Update(snippet); err != nil {
+		t.Fatal(err)
+	}
+	if err = m.Unlock(id, "pa55word123"); err != nil {
+		t.Errorf("want the password to be kept; got %v", err)
+	}
+
+	snippet.Password = "n3w-pa55word"
+	if err = m.Update(snippet); err != nil {
+		t.Fatal(err)
+	}
+	if err = m.Unlock(id, "n3w-pa55word"); err != nil {
+		t.Errorf("want the password to be replaced; got %v", err)
+	}
+
+	snippet.Password = ""
+	snippet.Protected = false
+	if err = m.Update(snippet); err != nil {
+		t.Fatal(err)
+	}
+	if snippet, err = m.Peek(id); err != nil || snippet.Protected {
+		t.Errorf("want the password to be removed; got %+v, %v", snippet, err)
+	}
+}
+
+func testSnippetRepositoryNeverExpires(t *testing.T, s *Stores) {
+	m := s.Snippets
+	insertUsers(t, s, "Alice")
+
+	id, err := m.Insert(&models.Snippet{UserID: 1, Title: "Runbook", Content: "Restart the service"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	scratch, err := m.Insert(&models.Snippet{UserID: 1, Title: "Scratch", Content: "Scratch", Expires: time.Now().UTC().Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = s.Expire(scratch); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := m.DeleteExpired(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("want only the expired snippet to be deleted; got %d", n)
+	}
+
+	snippet, err := m.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !snippet.Expires.IsZero() {
+		t.Errorf("want snippet without expiry time; got %v", snippet.Expires)
+	}
+
+	latest, err := m.Latest()
+	assertIDs(t, "only the snippet which never expires", latest, err, id)
+
+	if _, err = m.Get(scratch); !errors.Is(err, models.ErrNoRecord) {
+		t.Errorf("want %v for expired snippet; got %v", models.ErrNoRecord, err)
+	}
+}
+
+func testSnippetRepositoryForks(t *testing.T, s *Stores) {
+	m := s.Snippets
+	insertUsers(t, s, "Alice")
+
+	original, err := m.Insert(&models.Snippet{UserID: 1, Title: "Runbook", Content: "Restart the service"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fork, err := m.Insert(&models.Snippet{UserID: 1, Title: "Runbook", Content: "Restart the service twice", ForkedFromID: original})
+	if err != nil {
+		t.Fatal(err)
+	}
+	private, err := m.Insert(&models.Snippet{UserID: 1, Slug: "abc", Title: "Runbook", Content: "Secret", Visibility: models.VisibilityPrivate, ForkedFromID: original})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snippet, err := m.Get(fork)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snippet.ForkedFromID != original {
+		t.Errorf("want fork of %d; got %d", original, snippet.ForkedFromID)
+	}
+
+	forks, err := m.Forks(original)
+	assertIDs(t, "forks, newest first", forks, err, private, fork)
+
+	if err = m.Delete(original); err != nil {
+		t.Fatal(err)
+	}
+
+	snippet, err = m.Get(fork)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snippet.ForkedFromID != 0 {
+		t.Errorf("want fork of a deleted snippet to forget it; got %d", snippet.ForkedFromID)
+	}
+}
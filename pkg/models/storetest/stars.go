@@ -0,0 +1,66 @@
+package storetest
+
+import (
+	"testing"
+	"time"
+
+	"jackson.software/snippetbox/pkg/models"
+)
+
+func testStarRepository(t *testing.T, s *Stores) {
+	snippets := s.Snippets
+	m := s.Stars
+	insertUsers(t, s, "Alice", "Bob")
+
+	for _, snippet := range []*models.Snippet{
+		{UserID: 1, Title: "Runbook", Content: "systemctl restart api"},
+		{UserID: 1, Title: "Deploy", Content: "make deploy"},
+		{UserID: 1, Title: "Secret", Content: "x", Visibility: models.VisibilityPrivate},
+	} {
+		if _, err := snippets.Insert(snippet); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, st := range []struct{ snippetID, userID int }{{1, 2}, {2, 2}, {2, 1}, {3, 2}} {
+		starred, err := m.Toggle(st.snippetID, st.userID)
+		if err != nil || !starred {
+			t.Fatalf("want snippet %d to be starred by user %d; got %v, %v", st.snippetID, st.userID, starred, err)
+		}
+	}
+
+	if snippet, err := snippets.Peek(2); err != nil || snippet.Stars != 2 {
+		t.Errorf("want 2 stars; got %+v, %v", snippet, err)
+	}
+	if exists, err := m.Exists(1, 2); err != nil || !exists {
+		t.Errorf("want star to exist; got %v, %v", exists, err)
+	}
+	if exists, err := m.Exists(1, 1); err != nil || exists {
+		t.Errorf("want no star; got %v, %v", exists, err)
+	}
+
+	list, err := snippets.MostStarred(time.Time{}, 10)
+	assertIDs(t, "most starred ever", list, err, 2, 1)
+	if list[0].Stars != 2 || list[1].Stars != 1 {
+		t.Errorf("want star counts 2 and 1; got %d and %d", list[0].Stars, list[1].Stars)
+	}
+	list, err = snippets.MostStarred(time.Time{}, 1)
+	assertIDs(t, "most starred with limit", list, err, 2)
+	list, err = snippets.MostStarred(time.Now().Add(time.Hour), 10)
+	assertIDs(t, "most starred in the future", list, err)
+	list, err = snippets.Starred(2)
+	assertIDs(t, "starred by Bob without Alice's private snippet", list, err, 2, 1)
+
+	starred, err := m.Toggle(1, 2)
+	if err != nil || starred {
+		t.Fatalf("want star to be taken back; got %v, %v", starred, err)
+	}
+	list, err = snippets.Starred(2)
+	assertIDs(t, "starred by Bob", list, err, 2)
+
+	if err = snippets.Delete(2); err != nil {
+		t.Fatal(err)
+	}
+	list, err = snippets.MostStarred(time.Time{}, 10)
+	assertIDs(t, "most starred after delete", list, err)
+}
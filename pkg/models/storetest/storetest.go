@@ -0,0 +1,96 @@
+// Package storetest implements a conformance suite for the storage backends
+// of snippetbox. Every backend runs the same tests against its repositories,
+// so they all behave the same way.
+package storetest
+
+import (
+	"testing"
+	"time"
+
+	"jackson.software/snippetbox/pkg/models"
+)
+
+// Stores are the repositories of a storage backend, which all share the same
+// empty database, along with the hooks changing records in ways the
+// repositories don't support.
+type Stores struct {
+	Snippets    models.SnippetStore
+	Revisions   models.RevisionStore
+	Comments    models.CommentStore
+	Stars       models.StarStore
+	Collections models.CollectionStore
+	Users       models.UserStore
+	Tokens      models.TokenStore
+
+	// Expire makes the snippet with the given ID expire a minute ago.
+	Expire func(id int) error
+	// SetCreated changes the creation time of the snippet with the given ID.
+	SetCreated func(id int, created time.Time) error
+}
+
+// Run runs the conformance suite, calling newStores for every test to get
+// the repositories of an empty database.
+func Run(t *testing.T, newStores func(t *testing.T) *Stores) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, s *Stores)
+	}{
+		{"SnippetRepositoryGet", testSnippetRepositoryGet},
+		{"SnippetRepositoryLatest", testSnippetRepositoryLatest},
+		{"SnippetRepositoryUpdate", testSnippetRepositoryUpdate},
+		{"SnippetRepositoryDelete", testSnippetRepositoryDelete},
+		{"SnippetRepositoryDeleteExpired", testSnippetRepositoryDeleteExpired},
+		{"SnippetRepositoryPage", testSnippetRepositoryPage},
+		{"SnippetRepositorySearch", testSnippetRepositorySearch},
+		{"SnippetRepositoryTags", testSnippetRepositoryTags},
+		{"SnippetRepositoryFiles", testSnippetRepositoryFiles},
+		{"SnippetRepositoryVisibility", testSnippetRepositoryVisibility},
+		{"SnippetRepositoryBurnAfterReading", testSnippetRepositoryBurnAfterReading},
+		{"SnippetRepositoryPassword", testSnippetRepositoryPassword},
+		{"SnippetRepositoryNeverExpires", testSnippetRepositoryNeverExpires},
+		{"SnippetRepositoryForks", testSnippetRepositoryForks},
+		{"CommentRepository", testCommentRepository},
+		{"StarRepository", testStarRepository},
+		{"CollectionRepository", testCollectionRepository},
+		{"UserRepository", testUserRepository},
+		{"TokenRepository", testTokenRepository},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newStores(t))
+		})
+	}
+}
+
+// InsertUsers inserts a user for each of the given names, whose IDs count up
+// from 1.
+func insertUsers(t *testing.T, s *Stores, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		if err := s.Users.Insert(name, name+"@example.com", "pa55word123"); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// AssertIDs fails the test unless the given snippets have the wanted IDs, in
+// the same order.
+func assertIDs(t *testing.T, name string, got []*models.Snippet, err error, want ...int) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := []int{}
+	for _, s := range got {
+		ids = append(ids, s.ID)
+	}
+	if len(ids) != len(want) {
+		t.Fatalf("want %v %s; got %v", want, name, ids)
+	}
+	for i := range ids {
+		if ids[i] != want[i] {
+			t.Fatalf("want %v %s; got %v", want, name, ids)
+		}
+	}
+}
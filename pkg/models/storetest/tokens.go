@@ -0,0 +1,60 @@
+package storetest
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"jackson.software/snippetbox/pkg/models"
+)
+
+func testTokenRepository(t *testing.T, s *Stores) {
+	m := s.Tokens
+	insertUsers(t, s, "Alice")
+
+	token, err := m.Insert(&models.Token{UserID: 1, Name: "CI", Scopes: []string{models.ScopeSnippetsRead, models.ScopeSnippetsWrite}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(token, "sbx_") {
+		t.Errorf("want token starting with sbx_; got %q", token)
+	}
+
+	expired, err := m.Insert(&models.Token{UserID: 1, Name: "Old", Scopes: []string{models.ScopeSnippetsRead}, Expires: time.Now().Add(-time.Minute)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tok, err := m.Authenticate(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.UserID != 1 || tok.Name != "CI" || !tok.HasScope(models.ScopeSnippetsWrite) || tok.LastUsed.IsZero() {
+		t.Errorf("want used token CI of user 1 with write scope; got %+v", tok)
+	}
+
+	for _, invalid := range []string{expired, "sbx_unknown"} {
+		if _, err = m.Authenticate(invalid); !errors.Is(err, models.ErrInvalidCredentials) {
+			t.Errorf("want %v for token %q; got %v", models.ErrInvalidCredentials, invalid, err)
+		}
+	}
+
+	tokens, err := m.ForUser(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 2 || tokens[0].Name != "Old" || tokens[1].ID != tok.ID {
+		t.Fatalf("want both tokens, newest first; got %d", len(tokens))
+	}
+
+	if err = m.Delete(2, tok.ID); !errors.Is(err, models.ErrNoRecord) {
+		t.Errorf("want %v for token of another user; got %v", models.ErrNoRecord, err)
+	}
+	if err = m.Delete(1, tok.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = m.Authenticate(token); !errors.Is(err, models.ErrInvalidCredentials) {
+		t.Errorf("want %v for revoked token; got %v", models.ErrInvalidCredentials, err)
+	}
+}
@@ -0,0 +1,39 @@
+package storetest
+
+import (
+	"errors"
+	"testing"
+
+	"jackson.software/snippetbox/pkg/models"
+)
+
+func testUserRepository(t *testing.T, s *Stores) {
+	m := s.Users
+
+	if err := m.Insert("Alice", "alice@example.com", "pa55word123"); err != nil {
+		t.Fatal(err)
+	}
+
+	err := m.Insert("Bob", "ALICE@example.com", "pa55word123")
+	if !errors.Is(err, models.ErrDuplicateEmail) {
+		t.Errorf("want %v; got %v", models.ErrDuplicateEmail, err)
+	}
+
+	id, err := m.Authenticate("alice@example.com", "pa55word123")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = m.Authenticate("alice@example.com", "wrong password")
+	if !errors.Is(err, models.ErrInvalidCredentials) {
+		t.Errorf("want %v; got %v", models.ErrInvalidCredentials, err)
+	}
+
+	u, err := m.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Name != "Alice" || u.Email != "alice@example.com" || !u.Active {
+		t.Errorf("want active user Alice; got %+v", u)
+	}
+}
@@ -5,4 +5,36 @@ for (var i = 0; i < navLinks.length; i++) {
 		link.classList.add("live");
 		break;
 	}
-}
\ No newline at end of file
+}
+var commentLine = document.querySelector("#comment-form input[name='line']");
+var lineLinks = document.querySelectorAll("a[data-line]");
+for (var i = 0; i < lineLinks.length; i++) {
+	lineLinks[i].addEventListener("click", function(e) {
+		if (commentLine) {
+			commentLine.value = this.getAttribute("data-line");
+		}
+	});
+}
+var addFile = document.getElementById("add-file");
+var fileTemplate = document.getElementById("file-template");
+if (addFile && fileTemplate) {
+	addFile.addEventListener("click", function() {
+		// Files after the first one are numbered from 2, like their fields.
+		var next = 2;
+		var filenames = document.querySelectorAll(".files input[name^='filename.']");
+		for (var i = 0; i < filenames.length; i++) {
+			next = Math.max(next, parseInt(filenames[i].name.split(".")[1], 10) + 1);
+		}
+		var file = fileTemplate.content.cloneNode(true);
+		var fields = file.querySelectorAll("[name]");
+		for (var i = 0; i < fields.length; i++) {
+			fields[i].name = fields[i].name + "." + next;
+		}
+		document.querySelector(".files").appendChild(file);
+	});
+}
+document.addEventListener("click", function(e) {
+	if (e.target.classList.contains("remove-file")) {
+		e.target.parentNode.remove();
+	}
+});